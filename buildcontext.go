@@ -5,38 +5,59 @@ import (
 	"fmt"
 )
 
-type buildContext struct {
+type BuildContext struct {
+	b      Dialect
 	sql    *bytes.Buffer
 	params []interface{}
 }
 
-func newBuildContext() *buildContext {
-	return &buildContext{
+func newBuildContext(b Dialect) *BuildContext {
+	return &BuildContext{
+		b:   b,
 		sql: new(bytes.Buffer),
 	}
 }
 
-func (this *buildContext) AppendSql(strs ...string) *buildContext {
+func (this *BuildContext) AppendSql(strs ...string) *BuildContext {
 	for _, s := range strs {
 		this.sql.WriteString(s)
 	}
 	return this
 }
 
-func (this *buildContext) AppendSqlF(format string, args ...interface{}) *buildContext {
+func (this *BuildContext) AppendSqlF(format string, args ...interface{}) *BuildContext {
 	this.sql.WriteString(fmt.Sprintf(format, args...))
 	return this
 }
 
-func (this *buildContext) AddParam(params ...interface{}) *buildContext {
-	this.params = append(this.params, params...)
+// AppendParam adds params and appends a placeholder of the dialect for each of them
+func (this *BuildContext) AppendParam(params ...interface{}) *BuildContext {
+	for i, p := range params {
+		if i > 0 {
+			this.sql.WriteString(",")
+		}
+		this.params = append(this.params, p)
+		this.sql.WriteString(this.b.Placeholder(len(this.params)))
+	}
 	return this
 }
 
-func (this *buildContext) GetSql() string {
+// check returns err, or an error if count of parameters exceeds MaxParams of the dialect,
+// it must be called with the result of building a whole statement.
+func (this *BuildContext) check(err error) error {
+	if err != nil {
+		return err
+	}
+	if max := this.b.MaxParams(); max > 0 && len(this.params) > max {
+		return fmt.Errorf("too many parameters in a statement: %d, the database allows %d", len(this.params), max)
+	}
+	return nil
+}
+
+func (this *BuildContext) GetSql() string {
 	return this.sql.String()
 }
 
-func (this *buildContext) GetParams() []interface{} {
+func (this *BuildContext) GetParams() []interface{} {
 	return this.params
 }
@@ -1,8 +1,974 @@
 package gsd
 
-type builder interface {
-	BuildSelect(ctx *buildContext, info *selectInfo) error
-	BuildInsert(ctx *buildContext, info *insertInfo) error
-	BuildUpdate(ctx *buildContext, info *updateInfo) error
-	BuildDelete(ctx *buildContext, info *deleteInfo) error
+import (
+	"fmt"
+	"reflect"
+)
+
+/********** returningStyle **********/
+
+// returningStyle decides how returning columns of insert, update and delete actions are written
+type returningStyle int8
+
+const (
+	RETURNING_CLAUSE returningStyle = iota // RETURNING at the end of statement
+	RETURNING_OUTPUT                       // OUTPUT INSERTED.x or DELETED.x of SQL Server
+	RETURNING_NONE                         // not supported
+)
+
+/********** Builder **********/
+
+// Builder implements the Build methods of Dialect with standard SQL, database specific parts are
+// supplied by the Quote/Placeholder/Page hooks of the dialect which embeds it, and the options below
+// which can be set after NewBuilder.
+type Builder struct {
+	d Dialect
+	// Like is operator of FILTER_LK, default is LIKE
+	Like string
+	// NoRecursive omits RECURSIVE keyword of recursive cte, SQL Server doesn't accept it
+	NoRecursive bool
+	// Returning decides how returning columns are written, default is RETURNING_CLAUSE
+	Returning returningStyle
+	// MaxInItems is max items of an IN list, longer lists are split into OR'ed groups, default is MaxParams of the dialect
+	MaxInItems int
+	// MaxInsertRows is max rows of a multi-row insert statement, 0 means no limit
+	MaxInsertRows int
+}
+
+// NewBuilder creates a Builder which calls hooks of d, d is normally the dialect embedding the Builder
+func NewBuilder(d Dialect) *Builder {
+	return &Builder{d: d}
+}
+
+// builder returns this, so options of the Builder embedded in a dialect can be read by builderOf
+func (this *Builder) builder() *Builder {
+	return this
+}
+
+// builderOf returns the Builder embedded in d, or nil if d doesn't embed one
+func builderOf(d Dialect) *Builder {
+	if o, ok := d.(interface{ builder() *Builder }); ok {
+		return o.builder()
+	}
+	return nil
+}
+
+// Placeholder returns ?, which is used by most drivers
+func (this *Builder) Placeholder(index int) string {
+	return "?"
+}
+
+// MaxParams returns 0, which means count of parameters is not limited
+func (this *Builder) MaxParams() int {
+	return 0
+}
+
+// BuildInsert build query string and parameters for insert action
+func (this *Builder) BuildInsert(ctx *BuildContext, info *InsertInfo) (err error) {
+	if err = this.checkReturning(info.returning); err != nil {
+		return
+	}
+
+	ctx.AppendSql("INSERT INTO ", this.d.Quote(info.table))
+	if info.query != nil {
+		err = this.buildInsertSelect(ctx, info)
+	} else if info.rows != nil {
+		ctx.AppendSql("(")
+		err = this.buildInsertRows(ctx, info)
+	} else {
+		ctx.AppendSql("(")
+		this.buildInsertValues(ctx, info.values, info.returning)
+	}
+	if err == nil {
+		this.buildReturning(ctx, info.table, info.returning)
+	}
+	return
+}
+
+// checkReturning returns an error if returning columns are set but the database doesn't support them
+func (this *Builder) checkReturning(cols []string) error {
+	if len(cols) > 0 && this.Returning == RETURNING_NONE {
+		return fmt.Errorf("returning columns are not supported by this database")
+	}
+	return nil
+}
+
+// buildOutput writes OUTPUT clause of SQL Server, like: OUTPUT INSERTED.[ID], prefix is INSERTED or DELETED
+func (this *Builder) buildOutput(ctx *BuildContext, cols []string, prefix string) {
+	if this.Returning != RETURNING_OUTPUT || len(cols) == 0 {
+		return
+	}
+
+	ctx.AppendSql(" OUTPUT ")
+	for i, col := range cols {
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
+		ctx.AppendSql(prefix, ".", this.d.Quote(col))
+	}
+}
+
+// buildReturning writes RETURNING clause at the end of statement, columns are qualified with table,
+// so they are not ambiguous with columns of joined tables.
+func (this *Builder) buildReturning(ctx *BuildContext, table string, cols []string) {
+	if this.Returning != RETURNING_CLAUSE || len(cols) == 0 {
+		return
+	}
+
+	ctx.AppendSql(" RETURNING ")
+	for i, col := range cols {
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
+		ctx.AppendSql(this.d.Quote(table), ".", this.d.Quote(col))
+	}
+}
+
+// buildInsertValues writes columns and VALUES part of single row insert, output is written between them if it is set
+func (this *Builder) buildInsertValues(ctx *BuildContext, m map[string]interface{}, output []string) {
+	values := make([]interface{}, 0, len(m))
+	for _, k := range InsertValues(m).Keys() {
+		if len(values) > 0 {
+			ctx.AppendSql(",")
+		}
+		ctx.AppendSql(this.d.Quote(k))
+		values = append(values, m[k])
+	}
+
+	ctx.AppendSql(")")
+	this.buildOutput(ctx, output, "INSERTED")
+	ctx.AppendSql(" VALUES(")
+	ctx.AppendParam(values...)
+	ctx.AppendSql(")")
+}
+
+// buildInsertRows writes columns and rows of a multi-row insert statement
+func (this *Builder) buildInsertRows(ctx *BuildContext, info *InsertInfo) error {
+	for i, col := range info.columns {
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
+		ctx.AppendSql(this.d.Quote(col))
+	}
+
+	ctx.AppendSql(") VALUES")
+	for i, row := range info.rows {
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
+		ctx.AppendSql("(")
+		ctx.AppendParam(row...)
+		ctx.AppendSql(")")
+	}
+	return nil
+}
+
+// buildInsertSelect writes columns and query of INSERT ... SELECT statement, query can be a select or compound clause,
+// column list is omitted if columns are not set, then the query must return all columns of the table in order.
+func (this *Builder) buildInsertSelect(ctx *BuildContext, info *InsertInfo) error {
+	if len(info.columns) > 0 {
+		ctx.AppendSql("(")
+		for i, col := range info.columns {
+			if i > 0 {
+				ctx.AppendSql(",")
+			}
+			ctx.AppendSql(this.d.Quote(col))
+		}
+		ctx.AppendSql(")")
+	}
+	this.buildOutput(ctx, info.returning, "INSERTED")
+	ctx.AppendSql(" ")
+
+	switch q := info.query.(type) {
+	case *selectContext:
+		if err := checkNested(q); err != nil {
+			return err
+		}
+		return this.d.BuildSelect(ctx, q.info)
+	case *compoundContext:
+		// a parenthesized member right after table name would be taken as column list, so it is wrapped as derived table
+		ctx.AppendSql("SELECT * FROM ")
+		if err := this.buildSubquery(ctx, q); err != nil {
+			return err
+		}
+		ctx.AppendSql(" AS _C")
+		return nil
+	default:
+		return fmt.Errorf("invalid subquery: %v", info.query)
+	}
+}
+
+// BuildUpdate build query string and parameters for update action,
+// joined tables are written with UPDATE ... FROM, and their conditions are moved to WHERE.
+func (this *Builder) BuildUpdate(ctx *BuildContext, info *UpdateInfo) (err error) {
+	if err = this.checkReturning(info.returning); err != nil {
+		return
+	}
+
+	table := this.d.Quote(info.table)
+	ctx.AppendSql("UPDATE ", table, " SET")
+	if len(info.joins) == 0 {
+		this.buildSetValues(ctx, info.values, "", false)
+		this.buildOutput(ctx, info.returning, "INSERTED")
+		err = this.buildWhere(ctx, info.where)
+	} else {
+		this.buildSetValues(ctx, info.values, table+".", false)
+		ctx.AppendSql(" FROM ")
+		err = this.buildJoinsWhere(ctx, info.joins, info.where, "UPDATE")
+	}
+	if err == nil {
+		this.buildReturning(ctx, info.table, info.returning)
+	}
+	return
+}
+
+// buildJoinsWhere writes joined tables as a list, and WHERE with their conditions, like: b,c WHERE (on1) AND (on2) AND (where)
+func (this *Builder) buildJoinsWhere(ctx *BuildContext, joins []*joiner, where Filters, action string) error {
+	for i, j := range joins {
+		if j.jt != JOIN_INNER {
+			return fmt.Errorf("%s doesn't support %s in this database", action, j.jt)
+		}
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
+		if err := this.buildTable(ctx, j.t); err != nil {
+			return err
+		}
+	}
+
+	ctx.AppendSql(" WHERE ")
+	for i, j := range joins {
+		if i > 0 {
+			ctx.AppendSql(" AND ")
+		}
+		ctx.AppendSql("(")
+		if err := this.BuildFilters(ctx, j.on); err != nil {
+			return err
+		}
+		ctx.AppendSql(")")
+	}
+	if where != nil {
+		ctx.AppendSql(" AND (")
+		if err := this.BuildFilters(ctx, where); err != nil {
+			return err
+		}
+		ctx.AppendSql(")")
+	}
+	return nil
+}
+
+// buildJoins writes JOIN parts, like: JOIN b ON ...
+func (this *Builder) buildJoins(ctx *BuildContext, joins []*joiner) error {
+	for _, j := range joins {
+		ctx.AppendSqlF(" %s ", j.jt)
+		if err := this.buildTable(ctx, j.t); err != nil {
+			return err
+		}
+		ctx.AppendSql(" ON ")
+		if err := this.BuildFilters(ctx, j.on); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildDeleteJoins writes delete action with joined tables in the form of MySQL and SQL Server, like: DELETE a FROM a JOIN b ON ...
+func (this *Builder) buildDeleteJoins(ctx *BuildContext, info *DeleteInfo) error {
+	if err := this.checkReturning(info.returning); err != nil {
+		return err
+	}
+
+	table := this.d.Quote(info.table)
+	ctx.AppendSql("DELETE ", table)
+	this.buildOutput(ctx, info.returning, "DELETED")
+	ctx.AppendSql(" FROM ", table)
+	if err := this.buildJoins(ctx, info.joins); err != nil {
+		return err
+	}
+	return this.buildWhere(ctx, info.where)
+}
+
+// buildSetValues writes assignments of update values, prefix qualifies columns on the right side of UPDATE_INC,
+// and assigned columns too if qualified is true. Values of UPDATE_EQ can be an Expr, like: UV(Col(t, "NAME")).
+func (this *Builder) buildSetValues(ctx *BuildContext, values map[string]*updateValue, prefix string, qualified bool) {
+	first := true
+	for _, k := range UpdateValues(values).Keys() {
+		v := values[k]
+		if first {
+			first = false
+		} else {
+			ctx.AppendSql(",")
+		}
+
+		col, left := this.d.Quote(k), this.d.Quote(k)
+		if qualified {
+			left = prefix + col
+		}
+		switch v.ut {
+		case UPDATE_INC:
+			ctx.AppendSql(" ", left, "=", prefix, col, "+")
+			ctx.AppendParam(v.val)
+		case UPDATE_XP:
+			ctx.AppendSqlF(" %s=%s", left, v.val)
+		default:
+			ctx.AppendSql(" ", left, "=")
+			this.buildValue(ctx, v.val)
+		}
+	}
+}
+
+// BuildUpsert build query string and parameters for upsert action with INSERT ... ON CONFLICT ... DO UPDATE
+func (this *Builder) BuildUpsert(ctx *BuildContext, info *UpsertInfo) error {
+	if len(info.keys) == 0 {
+		return fmt.Errorf("conflict keys of upsert are not set")
+	}
+	if len(info.updates) == 0 {
+		return fmt.Errorf("update values of upsert are not set")
+	}
+
+	table := this.d.Quote(info.table)
+	ctx.AppendSql("INSERT INTO ", table, "(")
+	this.buildInsertValues(ctx, info.values, nil)
+
+	ctx.AppendSql(" ON CONFLICT(")
+	for i, k := range info.keys {
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
+		ctx.AppendSql(this.d.Quote(k))
+	}
+	ctx.AppendSql(") DO UPDATE SET")
+	this.buildSetValues(ctx, info.updates, table+".", false)
+	return nil
+}
+
+// BuildDelete build query string and parameters for delete action, joined tables are written with DELETE ... USING
+func (this *Builder) BuildDelete(ctx *BuildContext, info *DeleteInfo) (err error) {
+	if err = this.checkReturning(info.returning); err != nil {
+		return
+	}
+
+	ctx.AppendSql("DELETE FROM ", this.d.Quote(info.table))
+	if len(info.joins) == 0 {
+		this.buildOutput(ctx, info.returning, "DELETED")
+		err = this.buildWhere(ctx, info.where)
+	} else {
+		ctx.AppendSql(" USING ")
+		err = this.buildJoinsWhere(ctx, info.joins, info.where, "DELETE")
+	}
+	if err == nil {
+		this.buildReturning(ctx, info.table, info.returning)
+	}
+	return
+}
+
+// BuildSelect build query string and parameters for select action
+func (this *Builder) BuildSelect(ctx *BuildContext, info *SelectInfo) error {
+	if err := this.buildWith(ctx, info.ctes); err != nil {
+		return err
+	}
+
+	ctx.AppendSql("SELECT ")
+	if info.distinct {
+		ctx.AppendSql("DISTINCT ")
+	}
+	this.buildColumns(ctx, info.columns)
+
+	if err := this.BuildBody(ctx, info); err != nil {
+		return err
+	}
+	this.buildOrders(ctx, info.orders)
+
+	// LIMIT
+	if info.skip != 0 || info.take != 0 {
+		ctx.AppendSql(this.d.Page(info.skip, info.take))
+	}
+
+	return nil
+}
+
+// BuildCompound build query string and parameters for compound select action, like: (SELECT ...) UNION (SELECT ...)
+func (this *Builder) BuildCompound(ctx *BuildContext, info *CompoundInfo) error {
+	if err := this.buildCompoundQueries(ctx, info, true); err != nil {
+		return err
+	}
+	this.buildOrders(ctx, info.orders)
+
+	// LIMIT
+	if info.skip != 0 || info.take != 0 {
+		ctx.AppendSql(this.d.Page(info.skip, info.take))
+	}
+
+	return nil
+}
+
+// buildCompoundQueries writes queries of compound select action, paren decides whether queries are parenthesized
+func (this *Builder) buildCompoundQueries(ctx *BuildContext, info *CompoundInfo, paren bool) error {
+	for i, q := range info.queries {
+		if i > 0 {
+			ctx.AppendSqlF(" %s ", info.types[i-1])
+		}
+
+		if paren {
+			if err := this.buildSubquery(ctx, q); err != nil {
+				return err
+			}
+			continue
+		}
+
+		sc, ok := q.(*selectContext)
+		if !ok {
+			return fmt.Errorf("invalid subquery: %v", q)
+		}
+		if err := checkNested(sc); err != nil {
+			return err
+		}
+		// ORDER BY and LIMIT of an unparenthesized member would apply to the whole compound query
+		if len(sc.info.orders) > 0 || sc.info.skip != 0 || sc.info.take != 0 {
+			return fmt.Errorf("query #%d of compound select can't have ORDER BY or LIMIT, since queries are not parenthesized", i+1)
+		}
+		if err := this.d.BuildSelect(ctx, sc.info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildWith writes WITH clause, like: WITH RECURSIVE "tree" AS (...) ,
+// members of a compound cte are not parenthesized since recursive cte doesn't allow it in some databases.
+func (this *Builder) buildWith(ctx *BuildContext, ctes []*cte) error {
+	if len(ctes) == 0 {
+		return nil
+	}
+
+	ctx.AppendSql("WITH ")
+	if !this.NoRecursive {
+		for _, c := range ctes {
+			if c.recursive {
+				ctx.AppendSql("RECURSIVE ")
+				break
+			}
+		}
+	}
+
+	for i, c := range ctes {
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
+		ctx.AppendSql(this.d.Quote(c.name), " AS ")
+
+		if cc, ok := c.query.(*compoundContext); ok && len(cc.info.orders) == 0 && cc.info.skip == 0 && cc.info.take == 0 {
+			ctx.AppendSql("(")
+			if err := this.buildCompoundQueries(ctx, cc.info, false); err != nil {
+				return err
+			}
+			ctx.AppendSql(")")
+		} else if err := this.buildSubquery(ctx, c.query); err != nil {
+			return err
+		}
+	}
+	ctx.AppendSql(" ")
+	return nil
+}
+
+// buildColumns writes column list of select action
+func (this *Builder) buildColumns(ctx *BuildContext, columns []column) {
+	for i, c := range columns {
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
+
+		switch v := c.(type) {
+		case *normalColumn:
+			ctx.AppendSql(this.column(v.table, v.column))
+		case *exprColumn:
+			ctx.AppendSql(v.expr)
+		case *typedColumn:
+			this.buildExpr(ctx, v.expr)
+		}
+		if alias := c.Alias(); alias != "" {
+			ctx.AppendSql(" AS ", this.d.Quote(alias))
+		}
+	}
+}
+
+// BuildWith writes WITH clause of select action, it writes nothing if the action has no cte
+func (this *Builder) BuildWith(ctx *BuildContext, info *SelectInfo) error {
+	return this.buildWith(ctx, info.ctes)
+}
+
+// BuildColumns writes column list of select action
+func (this *Builder) BuildColumns(ctx *BuildContext, info *SelectInfo) {
+	this.buildColumns(ctx, info.columns)
+}
+
+// BuildBody writes FROM, JOIN, WHERE, GROUP BY and HAVING parts of select action
+func (this *Builder) BuildBody(ctx *BuildContext, info *SelectInfo) error {
+	// FROM
+	ctx.AppendSql(" FROM ")
+	if err := this.buildTable(ctx, info.table); err != nil {
+		return err
+	}
+
+	// JOIN
+	if err := this.buildJoins(ctx, info.joins); err != nil {
+		return err
+	}
+
+	if err := this.buildWhere(ctx, info.where); err != nil {
+		return err
+	}
+
+	// GROUP BY
+	if len(info.groups) > 0 {
+		ctx.AppendSql(" GROUP BY ")
+		this.buildGroupers(ctx, info.groups)
+
+		if info.having != nil {
+			ctx.AppendSql(" HAVING ")
+			if err := this.BuildFilters(ctx, info.having); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (this *Builder) buildGroupers(ctx *BuildContext, groups []*grouper) {
+	for i, g := range groups {
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
+		for j, col := range g.columns {
+			if j > 0 {
+				ctx.AppendSql(",")
+			}
+			ctx.AppendSql(this.column(g.table, col))
+		}
+		for j, e := range g.exprs {
+			if j > 0 {
+				ctx.AppendSql(",")
+			}
+			this.buildExpr(ctx, e)
+		}
+	}
+}
+
+// BuildOrders writes ORDER BY part of select action
+func (this *Builder) BuildOrders(ctx *BuildContext, info *SelectInfo) {
+	this.buildOrders(ctx, info.orders)
+}
+
+// buildOrders writes ORDER BY part of select or compound action
+func (this *Builder) buildOrders(ctx *BuildContext, orders []*sorter) {
+	if len(orders) > 0 {
+		ctx.AppendSql(" ORDER BY ")
+		this.buildSorters(ctx, orders)
+	}
+}
+
+func (this *Builder) buildSorters(ctx *BuildContext, orders []*sorter) {
+	for i, order := range orders {
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
+		if order.expr != nil {
+			this.buildExpr(ctx, order.expr)
+		}
+		for j, col := range order.columns {
+			if j > 0 {
+				ctx.AppendSql(",")
+			}
+			ctx.AppendSql(this.column(order.table, col))
+		}
+		ctx.AppendSqlF(" %s", order.st)
+	}
+}
+
+// buildTable writes table with alias, derived table is written as subquery, like: (SELECT ...) AS "x",
+// aliases are quoted since columns are referenced with quoted prefix.
+func (this *Builder) buildTable(ctx *BuildContext, t Table) error {
+	if st, ok := t.(*subTable); ok {
+		if st.alias == "" {
+			return fmt.Errorf("alias of derived table is not set")
+		}
+		if err := this.buildSubquery(ctx, st.query); err != nil {
+			return err
+		}
+		ctx.AppendSql(" AS ", this.d.Quote(st.alias))
+		return nil
+	}
+
+	ctx.AppendSql(this.d.Quote(t.Name()))
+	if t.Alias() != "" {
+		ctx.AppendSql(" AS ", this.d.Quote(t.Alias()))
+	}
+	return nil
+}
+
+func (this *Builder) buildWhere(ctx *BuildContext, where Filters) error {
+	if where == nil {
+		return nil
+	}
+
+	ctx.AppendSql(" WHERE ")
+	return this.BuildFilters(ctx, where)
+}
+
+// column returns quoted column name with table prefix if t is not nil
+func (this *Builder) column(t Table, col string) string {
+	if t == nil {
+		return this.d.Quote(col)
+	}
+	return this.d.Quote(t.Prefix()) + "." + this.d.Quote(col)
+}
+
+func (this *Builder) BuildFilters(ctx *BuildContext, filters Filters) error {
+	switch v := filters.(type) {
+	case *basicFilters:
+		for i, f := range v.items {
+			if i > 0 {
+				ctx.AppendSql(" AND ")
+			}
+			err := this.BuildFilter(ctx, f)
+			if err != nil {
+				return err
+			}
+		}
+	case *notFilters:
+		ctx.AppendSql("NOT(")
+		err := this.BuildFilters(ctx, v.inner)
+		if err != nil {
+			return err
+		}
+		ctx.AppendSql(")")
+	case *andFilters:
+		ctx.AppendSql("(")
+		err := this.BuildFilters(ctx, v.left)
+		if err != nil {
+			return err
+		}
+		ctx.AppendSql(") AND (")
+		err = this.BuildFilters(ctx, v.right)
+		if err != nil {
+			return err
+		}
+		ctx.AppendSql(")")
+	case *orFilters:
+		ctx.AppendSql("(")
+		err := this.BuildFilters(ctx, v.left)
+		if err != nil {
+			return err
+		}
+		ctx.AppendSql(") OR (")
+		err = this.BuildFilters(ctx, v.right)
+		if err != nil {
+			return err
+		}
+		ctx.AppendSql(")")
+	}
+
+	return nil
+}
+
+func (this *Builder) BuildFilter(ctx *BuildContext, filter interface{}) (err error) {
+	switch f := filter.(type) {
+	case *oneColumnFilter:
+		err = this.BuildOneColumnFilter(ctx, f)
+	case *twoColumnFilter:
+		err = this.BuildTwoColumnFilter(ctx, f)
+	case *exprFilter:
+		ctx.AppendSql(f.expr)
+	case *existsFilter:
+		if f.not {
+			ctx.AppendSql("NOT ")
+		}
+		ctx.AppendSql("EXISTS")
+		err = this.buildSubquery(ctx, f.query)
+	default:
+		err = fmt.Errorf("invalid filter: %v", filter)
+	}
+	return
+}
+
+func (this *Builder) BuildOneColumnFilter(ctx *BuildContext, f *oneColumnFilter) error {
+	left := f.expr
+	if left == nil {
+		left = &colExpr{table: f.table, column: f.column}
+	}
+
+	switch f.value.(type) {
+	case *selectContext, *compoundContext:
+		return this.buildSubqueryFilter(ctx, left, f)
+	}
+
+	switch f.ft {
+	case FILTER_IN:
+		return this.buildIn(ctx, left, f.value, false)
+	case FILTER_NIN:
+		return this.buildIn(ctx, left, f.value, true)
+	case FILTER_BETWEEN:
+		if len(flatten(f.value)) != 2 {
+			return fmt.Errorf("value of BETWEEN filter must be a slice with 2 items: %v", f.value)
+		}
+	}
+
+	this.buildExpr(ctx, left)
+	switch f.ft {
+	case FILTER_NE:
+		if f.value == nil {
+			ctx.AppendSql(" IS NOT NULL")
+		} else {
+			ctx.AppendSql("<>")
+			this.buildValue(ctx, f.value)
+		}
+	case FILTER_LT, FILTER_GT, FILTER_LTE, FILTER_GTE:
+		ctx.AppendSql(f.ft.operator())
+		this.buildValue(ctx, f.value)
+	case FILTER_LK, FILTER_NLK, FILTER_START, FILTER_END:
+		this.buildLike(ctx, f)
+	case FILTER_BETWEEN:
+		values := flatten(f.value)
+		ctx.AppendSql(" BETWEEN ")
+		this.buildValue(ctx, values[0])
+		ctx.AppendSql(" AND ")
+		this.buildValue(ctx, values[1])
+	case FILTER_NULL:
+		ctx.AppendSql(" IS NULL")
+	case FILTER_NNULL:
+		ctx.AppendSql(" IS NOT NULL")
+	default:
+		if f.value == nil {
+			ctx.AppendSql(" IS NULL")
+		} else {
+			ctx.AppendSql("=")
+			this.buildValue(ctx, f.value)
+		}
+	}
+
+	return nil
+}
+
+// buildValue writes v if it is an Expr, otherwise binds it as a parameter
+func (this *Builder) buildValue(ctx *BuildContext, v interface{}) {
+	if e, ok := v.(Expr); ok {
+		this.buildExpr(ctx, e)
+	} else {
+		ctx.AppendParam(v)
+	}
+}
+
+// buildExpr writes typed expression e, values in it are bound as parameters
+func (this *Builder) buildExpr(ctx *BuildContext, e Expr) {
+	switch v := e.(type) {
+	case *colExpr:
+		ctx.AppendSql(this.column(v.table, v.column))
+	case *valueExpr:
+		ctx.AppendParam(v.value)
+	case *rawExpr:
+		ctx.AppendSql(v.sql)
+	case *funcExpr:
+		ctx.AppendSql(v.name, "(")
+		if v.distinct {
+			ctx.AppendSql("DISTINCT ")
+		}
+		for i, arg := range v.args {
+			if i > 0 {
+				ctx.AppendSql(",")
+			}
+			this.buildExpr(ctx, arg)
+		}
+		ctx.AppendSql(")")
+	case *castExpr:
+		ctx.AppendSql("CAST(")
+		this.buildExpr(ctx, v.e)
+		ctx.AppendSql(" AS ", v.typ, ")")
+	case *overExpr:
+		this.buildExpr(ctx, v.e)
+		ctx.AppendSql(" OVER(")
+		if len(v.partitions) > 0 {
+			ctx.AppendSql("PARTITION BY ")
+			this.buildGroupers(ctx, v.partitions)
+		}
+		if len(v.orders) > 0 {
+			if len(v.partitions) > 0 {
+				ctx.AppendSql(" ")
+			}
+			ctx.AppendSql("ORDER BY ")
+			this.buildSorters(ctx, v.orders)
+		}
+		ctx.AppendSql(")")
+	case *binaryExpr:
+		ctx.AppendSql("(")
+		this.buildExpr(ctx, v.left)
+		ctx.AppendSql(v.op)
+		this.buildExpr(ctx, v.right)
+		ctx.AppendSql(")")
+	default:
+		ctx.AppendSql("NULL")
+	}
+}
+
+// buildLike writes filters of LIKE family, wildcards are bound with the value, so the same SQL works on every database.
+// %, _ and [ in value are escaped, so they are matched literally.
+func (this *Builder) buildLike(ctx *BuildContext, f *oneColumnFilter) {
+	like := this.Like
+	if like == "" {
+		like = "LIKE"
+	}
+	if f.ft == FILTER_NLK {
+		like = "NOT " + like
+	}
+
+	value := _LikeReplacer.Replace(fmt.Sprint(f.value))
+	switch f.ft {
+	case FILTER_START:
+		value = value + "%"
+	case FILTER_END:
+		value = "%" + value
+	default:
+		value = "%" + value + "%"
+	}
+
+	ctx.AppendSql(" ", like, " ")
+	ctx.AppendParam(value)
+	ctx.AppendSql(" ESCAPE '", likeEscape, "'")
+}
+
+// buildIn writes [NOT] IN filter with a placeholder for each item of value, empty list matches nothing for IN,
+// and everything for NOT IN. Lists longer than MaxInItems are split into groups, like: (x IN(...) OR x IN(...)),
+// but count of parameters in the whole statement is still limited by MaxParams of the dialect.
+// For compatibility, string value is written into SQL directly, like: x IN(1,2,3), it must not come from user input.
+func (this *Builder) buildIn(ctx *BuildContext, left Expr, value interface{}, not bool) error {
+	op, join, empty := " IN(", " OR ", "1=0"
+	if not {
+		op, join, empty = " NOT IN(", " AND ", "1=1"
+	}
+
+	if s, ok := value.(string); ok {
+		this.buildExpr(ctx, left)
+		ctx.AppendSql(op, s, ")")
+		return nil
+	}
+
+	values := flatten(value)
+	if len(values) == 0 {
+		ctx.AppendSql(empty)
+		return nil
+	}
+
+	size := this.MaxInItems
+	if size <= 0 {
+		size = this.d.MaxParams()
+	}
+	if size <= 0 || size > len(values) {
+		size = len(values)
+	}
+
+	if size < len(values) {
+		ctx.AppendSql("(")
+	}
+	for i := 0; i < len(values); i += size {
+		if i > 0 {
+			ctx.AppendSql(join)
+		}
+
+		end := i + size
+		if end > len(values) {
+			end = len(values)
+		}
+		this.buildExpr(ctx, left)
+		ctx.AppendSql(op)
+		ctx.AppendParam(values[i:end]...)
+		ctx.AppendSql(")")
+	}
+	if size < len(values) {
+		ctx.AppendSql(")")
+	}
+	return nil
+}
+
+// isList reports whether value is a slice or array(except []byte)
+func isList(value interface{}) bool {
+	if _, ok := value.([]byte); ok {
+		return false
+	}
+
+	k := reflect.ValueOf(value).Kind()
+	return k == reflect.Slice || k == reflect.Array
+}
+
+// flatten returns items of value if it is a slice or array(except []byte), otherwise value itself
+func flatten(value interface{}) []interface{} {
+	if !isList(value) {
+		return []interface{}{value}
+	}
+
+	v := reflect.ValueOf(value)
+	values := make([]interface{}, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values
+}
+
+// buildSubqueryFilter writes filter comparing left with a subquery, like: ID IN(SELECT ...)
+func (this *Builder) buildSubqueryFilter(ctx *BuildContext, left Expr, f *oneColumnFilter) error {
+	op := f.ft.operator()
+	switch f.ft {
+	case FILTER_IN:
+		op = " IN"
+	case FILTER_NIN:
+		op = " NOT IN"
+	}
+	if op == "" {
+		return fmt.Errorf("invalid filterType with subquery: %v", f.ft)
+	}
+
+	this.buildExpr(ctx, left)
+	ctx.AppendSql(op)
+
+	return this.buildSubquery(ctx, f.value)
+}
+
+// buildSubquery writes q in parentheses, parameters of q are added to ctx in place
+func (this *Builder) buildSubquery(ctx *BuildContext, q interface{}) (err error) {
+	if err = checkNested(q); err != nil {
+		return err
+	}
+
+	ctx.AppendSql("(")
+	switch v := q.(type) {
+	case *selectContext:
+		err = this.d.BuildSelect(ctx, v.info)
+	case *compoundContext:
+		err = this.d.BuildCompound(ctx, v.info)
+	default:
+		return fmt.Errorf("invalid subquery: %v", q)
+	}
+	if err != nil {
+		return err
+	}
+	ctx.AppendSql(")")
+	return nil
+}
+
+// checkNested returns an error if q is a select with WITH clause, WITH must be at the beginning of a statement,
+// so ctes of a subquery, a compound member or an insert source should be added to the outermost select.
+func checkNested(q interface{}) error {
+	if sc, ok := q.(*selectContext); ok && len(sc.info.ctes) > 0 {
+		return fmt.Errorf("select with WITH clause can't be nested in another statement")
+	}
+	return nil
+}
+
+func (this *Builder) BuildTwoColumnFilter(ctx *BuildContext, f *twoColumnFilter) error {
+	op := f.ft.operator()
+	if op == "" {
+		return fmt.Errorf("invalid filterType: %v", f.ft)
+	}
+
+	ctx.AppendSql(this.column(f.table1, f.column1), op, this.column(f.table2, f.column2))
+	return nil
 }
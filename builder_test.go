@@ -0,0 +1,49 @@
+package gsd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// _TestProviders are the built-in dialects, every golden test must cover all of them
+var _TestProviders = []string{"mysql", "mssql", "mssql2005", "sqlite", "postgres"}
+
+// golden is the expected result of building a clause, err means building must fail
+type golden struct {
+	sql  string
+	args []interface{}
+	err  bool
+}
+
+// testBuild builds the clause returned by build with every built-in dialect, and compares the result with cases
+func testBuild(t *testing.T, build func(d Dialect) interface{}, cases map[string]golden) {
+	t.Helper()
+
+	for _, p := range _TestProviders {
+		want, ok := cases[p]
+		if !ok {
+			t.Errorf("%s: golden result is missing", p)
+			continue
+		}
+
+		d, _ := getDialect(p)
+		sql, args, err := Debug(build(d))
+		if want.err {
+			if err == nil {
+				t.Errorf("%s: expect error, got %s", p, sql)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", p, err)
+			continue
+		}
+		if sql != want.sql {
+			t.Errorf("%s: sql mismatch\n got: %s\nwant: %s", p, sql, want.sql)
+		}
+		if !reflect.DeepEqual(args, want.args) {
+			t.Errorf("%s: args mismatch\n got: %#v\nwant: %#v", p, args, want.args)
+		}
+	}
+}
@@ -1,18 +1,37 @@
 package gsd
 
+import (
+	"context"
+)
+
 /********** Common **********/
 
 type ResultClause interface {
 	Result() (Result, error)
+	ResultContext(ctx context.Context) (Result, error)
 }
 
 type RowClause interface {
 	Row() Row
 	Rows() Rows
+	RowContext(ctx context.Context) Row
+	RowsContext(ctx context.Context) Rows
+}
+
+// ReturningClause can return columns of affected rows instead of the result
+type ReturningClause interface {
+	ResultClause
+	Returning(cols ...string) RowClause
 }
 
 /********** Select Clauses **********/
 
+type WithClause interface {
+	With(name string, q interface{}) WithClause
+	WithRecursive(name string, q interface{}) WithClause
+	Select(columns *Columns) SelectClause
+}
+
 type SelectClause interface {
 	From(t Table) FromClause
 }
@@ -55,31 +74,69 @@ type LimitClause interface {
 	Limit(skip, take int32) RowClause
 }
 
+/********** Compound Clauses **********/
+
+type CompoundClause interface {
+	RowClause
+	LimitClause
+	Union(q interface{}) CompoundClause
+	UnionAll(q interface{}) CompoundClause
+	Intersect(q interface{}) CompoundClause
+	Except(q interface{}) CompoundClause
+	OrderBy(s *Sorters) OrderByClause
+}
+
 /********** Update Clauses **********/
 
 type UpdateClause interface {
+	Join(t Table, on Filters) UpdateClause
+	LeftJoin(t Table, on Filters) UpdateClause
 	Set(values UpdateValues) SetClause
 }
 
 type SetClause interface {
-	ResultClause
-	Where(f Filters) ResultClause
+	ReturningClause
+	Where(f Filters) ReturningClause
 }
 
 /********** Delete Clauses **********/
 
 type DeleteClause interface {
-	Where(f Filters) ResultClause
+	Join(t Table, on Filters) DeleteClause
+	LeftJoin(t Table, on Filters) DeleteClause
+	Where(f Filters) ReturningClause
 }
 
 /********** Insert Clauses **********/
 
 type InsertClause interface {
 	Values(values InsertValues) InsertResultClause
+	ValuesBatch(values []InsertValues) ResultClause
+	Columns(cols ...string) InsertColumnsClause
+}
+
+type InsertColumnsClause interface {
+	From(q interface{}) InsertResultClause
 }
 
 type InsertResultClause interface {
 	Result() (InsertResult, error)
+	ResultContext(ctx context.Context) (InsertResult, error)
+	Returning(cols ...string) RowClause
+}
+
+/********** Upsert Clauses **********/
+
+type UpsertClause interface {
+	Values(values InsertValues) UpsertValuesClause
+}
+
+type UpsertValuesClause interface {
+	Keys(cols ...string) UpsertKeysClause
+}
+
+type UpsertKeysClause interface {
+	Update(values UpdateValues) ResultClause
 }
 
 /********** Execute Clauses **********/
@@ -91,4 +148,5 @@ type ExecuteClause interface {
 
 type ExecuteResultClause interface {
 	Result() (ExecuteResult, error)
+	ResultContext(ctx context.Context) (ExecuteResult, error)
 }
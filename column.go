@@ -46,6 +46,12 @@ func (this *Columns) AddE(expr, alias string) *Columns {
 	return this
 }
 
+// add typed expression column, like: AddX(gsd.CountAll(), "TOTAL")
+func (this *Columns) AddX(e Expr, alias string) *Columns {
+	this.columns = append(this.columns, &typedColumn{expr: e, alias: alias})
+	return this
+}
+
 /********** NormalColumn **********/
 
 type normalColumn struct {
@@ -68,3 +74,14 @@ type exprColumn struct {
 func (this *exprColumn) Alias() string {
 	return this.alias
 }
+
+/********** TypedColumn **********/
+
+type typedColumn struct {
+	expr  Expr
+	alias string
+}
+
+func (this *typedColumn) Alias() string {
+	return this.alias
+}
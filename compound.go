@@ -0,0 +1,109 @@
+package gsd
+
+import (
+	"context"
+)
+
+/********** compoundType **********/
+
+type compoundType int8
+
+const (
+	COMPOUND_UNION compoundType = iota
+	COMPOUND_UNION_ALL
+	COMPOUND_INTERSECT
+	COMPOUND_EXCEPT
+)
+
+func (this compoundType) String() string {
+	switch this {
+	case COMPOUND_UNION_ALL:
+		return "UNION ALL"
+	case COMPOUND_INTERSECT:
+		return "INTERSECT"
+	case COMPOUND_EXCEPT:
+		return "EXCEPT"
+	default:
+		return "UNION"
+	}
+}
+
+/********** CompoundInfo **********/
+
+type CompoundInfo struct {
+	queries []interface{}  // select clauses
+	types   []compoundType // types[i] combines queries[i+1] with the preceding queries
+	orders  []*sorter
+	skip    int32
+	take    int32
+}
+
+/********** compoundContext **********/
+
+type compoundContext struct {
+	exe  executor
+	b    Dialect
+	info *CompoundInfo
+}
+
+func newCompoundContext(exe executor, b Dialect, info *CompoundInfo) *compoundContext {
+	return &compoundContext{
+		exe:  exe,
+		b:    b,
+		info: info,
+	}
+}
+
+func (this *compoundContext) add(ct compoundType, q interface{}) CompoundClause {
+	this.info.types = append(this.info.types, ct)
+	this.info.queries = append(this.info.queries, q)
+	return this
+}
+
+func (this *compoundContext) Union(q interface{}) CompoundClause {
+	return this.add(COMPOUND_UNION, q)
+}
+
+func (this *compoundContext) UnionAll(q interface{}) CompoundClause {
+	return this.add(COMPOUND_UNION_ALL, q)
+}
+
+func (this *compoundContext) Intersect(q interface{}) CompoundClause {
+	return this.add(COMPOUND_INTERSECT, q)
+}
+
+func (this *compoundContext) Except(q interface{}) CompoundClause {
+	return this.add(COMPOUND_EXCEPT, q)
+}
+
+// OrderBy sorts the combined result, sorters should not reference tables, like: new(Sorters).Add(SORT_ASC, "ID")
+func (this *compoundContext) OrderBy(s *Sorters) OrderByClause {
+	this.info.orders = s.sorters
+	return this
+}
+
+func (this *compoundContext) Limit(skip, take int32) RowClause {
+	this.info.skip = skip
+	this.info.take = take
+	return this
+}
+
+func (this *compoundContext) Row() Row {
+	return this.RowContext(context.Background())
+}
+
+func (this *compoundContext) Rows() Rows {
+	return this.RowsContext(context.Background())
+}
+
+func (this *compoundContext) RowContext(c context.Context) Row {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildCompound(ctx, this.info))
+	return newBuiltRow(this.exe, c, ctx, err)
+}
+
+func (this *compoundContext) RowsContext(c context.Context) Rows {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildCompound(ctx, this.info))
+	return newBuiltRows(this.exe, c, ctx, err)
+}
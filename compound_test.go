@@ -0,0 +1,88 @@
+package gsd
+
+import (
+	"testing"
+)
+
+// unionAB returns a union of table A and B sorted by ID, it is paged if skip or take isn't 0
+func unionAB(skip, take int32, cols func(t Table) *Columns) func(d Dialect) interface{} {
+	return func(d Dialect) interface{} {
+		a, b := T("A"), T("B")
+		q1 := newSelectContext(nil, d, &SelectInfo{columns: cols(a).columns})
+		q1.From(a).Where(F().Add("X", 1))
+		q2 := newSelectContext(nil, d, &SelectInfo{columns: cols(b).columns})
+		q2.From(b)
+		cc := newCompoundContext(nil, d, &CompoundInfo{queries: []interface{}{q1}})
+		cc.Union(q2).OrderBy(new(Sorters).Add(SORT_ASC, "ID"))
+		if skip != 0 || take != 0 {
+			cc.Limit(skip, take)
+		}
+		return cc
+	}
+}
+
+func idName(t Table) *Columns {
+	return C(false).Add(t, "ID").AddA(t, "NAME", "N")
+}
+
+func TestCompound(t *testing.T) {
+	testBuild(t, unionAB(0, 0, idName), map[string]golden{
+		"mysql":     {sql: "(SELECT `A`.`ID`,`A`.`NAME` AS `N` FROM `A` WHERE `X`=?) UNION (SELECT `B`.`ID`,`B`.`NAME` AS `N` FROM `B`) ORDER BY `ID` ASC", args: []interface{}{1}},
+		"mssql":     {sql: `(SELECT [A].[ID],[A].[NAME] AS [N] FROM [A] WHERE [X]=?) UNION (SELECT [B].[ID],[B].[NAME] AS [N] FROM [B]) ORDER BY [ID] ASC`, args: []interface{}{1}},
+		"mssql2005": {sql: `(SELECT [A].[ID],[A].[NAME] AS [N] FROM [A] WHERE [X]=?) UNION (SELECT [B].[ID],[B].[NAME] AS [N] FROM [B]) ORDER BY [ID] ASC`, args: []interface{}{1}},
+		"sqlite":    {sql: `SELECT "A"."ID","A"."NAME" AS "N" FROM "A" WHERE "X"=? UNION SELECT "B"."ID","B"."NAME" AS "N" FROM "B" ORDER BY "ID" ASC`, args: []interface{}{1}},
+		"postgres":  {sql: `(SELECT "A"."ID","A"."NAME" AS "N" FROM "A" WHERE "X"=$1) UNION (SELECT "B"."ID","B"."NAME" AS "N" FROM "B") ORDER BY "ID" ASC`, args: []interface{}{1}},
+	})
+}
+
+func TestCompoundTop(t *testing.T) {
+	testBuild(t, unionAB(0, 10, idName), map[string]golden{
+		"mysql":     {sql: "(SELECT `A`.`ID`,`A`.`NAME` AS `N` FROM `A` WHERE `X`=?) UNION (SELECT `B`.`ID`,`B`.`NAME` AS `N` FROM `B`) ORDER BY `ID` ASC LIMIT 0,10", args: []interface{}{1}},
+		"mssql":     {sql: `(SELECT [A].[ID],[A].[NAME] AS [N] FROM [A] WHERE [X]=?) UNION (SELECT [B].[ID],[B].[NAME] AS [N] FROM [B]) ORDER BY [ID] ASC OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`, args: []interface{}{1}},
+		"mssql2005": {sql: `SELECT TOP 10 * FROM ((SELECT [A].[ID],[A].[NAME] AS [N] FROM [A] WHERE [X]=?) UNION (SELECT [B].[ID],[B].[NAME] AS [N] FROM [B])) AS _C ORDER BY [ID] ASC`, args: []interface{}{1}},
+		"sqlite":    {sql: `SELECT "A"."ID","A"."NAME" AS "N" FROM "A" WHERE "X"=? UNION SELECT "B"."ID","B"."NAME" AS "N" FROM "B" ORDER BY "ID" ASC LIMIT 10 OFFSET 0`, args: []interface{}{1}},
+		"postgres":  {sql: `(SELECT "A"."ID","A"."NAME" AS "N" FROM "A" WHERE "X"=$1) UNION (SELECT "B"."ID","B"."NAME" AS "N" FROM "B") ORDER BY "ID" ASC LIMIT 10 OFFSET 0`, args: []interface{}{1}},
+	})
+}
+
+func TestCompoundPage(t *testing.T) {
+	testBuild(t, unionAB(20, 10, idName), map[string]golden{
+		"mysql":     {sql: "(SELECT `A`.`ID`,`A`.`NAME` AS `N` FROM `A` WHERE `X`=?) UNION (SELECT `B`.`ID`,`B`.`NAME` AS `N` FROM `B`) ORDER BY `ID` ASC LIMIT 20,10", args: []interface{}{1}},
+		"mssql":     {sql: `(SELECT [A].[ID],[A].[NAME] AS [N] FROM [A] WHERE [X]=?) UNION (SELECT [B].[ID],[B].[NAME] AS [N] FROM [B]) ORDER BY [ID] ASC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY`, args: []interface{}{1}},
+		"mssql2005": {sql: `SELECT [ID],[N] FROM (SELECT _C.*,ROW_NUMBER() OVER(ORDER BY [ID] ASC) AS _N FROM ((SELECT [A].[ID],[A].[NAME] AS [N] FROM [A] WHERE [X]=?) UNION (SELECT [B].[ID],[B].[NAME] AS [N] FROM [B])) AS _C) AS _T WHERE _N>20 AND _N<=30`, args: []interface{}{1}},
+		"sqlite":    {sql: `SELECT "A"."ID","A"."NAME" AS "N" FROM "A" WHERE "X"=? UNION SELECT "B"."ID","B"."NAME" AS "N" FROM "B" ORDER BY "ID" ASC LIMIT 10 OFFSET 20`, args: []interface{}{1}},
+		"postgres":  {sql: `(SELECT "A"."ID","A"."NAME" AS "N" FROM "A" WHERE "X"=$1) UNION (SELECT "B"."ID","B"."NAME" AS "N" FROM "B") ORDER BY "ID" ASC LIMIT 10 OFFSET 20`, args: []interface{}{1}},
+	})
+}
+
+func TestCompoundPageExpr(t *testing.T) {
+	// the paging wrapper of SQL Server 2005 can't reference an expression without alias
+	d, _ := getDialect("mssql2005")
+	build := unionAB(20, 10, func(t Table) *Columns { return C(false).AddE("COUNT(*)", "") })
+	if sql, _, err := Debug(build(d)); err == nil {
+		t.Errorf("expect error, got %s", sql)
+	}
+
+	build = unionAB(20, 10, func(t Table) *Columns { return C(false).AddE("COUNT(*)", "TOTAL") })
+	if _, _, err := Debug(build(d)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCompoundMemberOrder(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		a, b := T("A"), T("B")
+		q1 := newSelectContext(nil, d, &SelectInfo{columns: C(false).Add(a, "ID").columns})
+		q1.From(a)
+		q1.OrderBy(new(Sorters).Add(SORT_ASC, "ID"))
+		q2 := newSelectContext(nil, d, &SelectInfo{columns: C(false).Add(b, "ID").columns})
+		q2.From(b)
+		return newCompoundContext(nil, d, &CompoundInfo{queries: []interface{}{q1}}).UnionAll(q2)
+	}, map[string]golden{
+		"mysql":     {sql: "(SELECT `A`.`ID` FROM `A` ORDER BY `ID` ASC) UNION ALL (SELECT `B`.`ID` FROM `B`)"},
+		"mssql":     {sql: `(SELECT [A].[ID] FROM [A] ORDER BY [ID] ASC) UNION ALL (SELECT [B].[ID] FROM [B])`},
+		"mssql2005": {sql: `(SELECT [A].[ID] FROM [A] ORDER BY [ID] ASC) UNION ALL (SELECT [B].[ID] FROM [B])`},
+		"sqlite":    {err: true},
+		"postgres":  {sql: `(SELECT "A"."ID" FROM "A" ORDER BY "ID" ASC) UNION ALL (SELECT "B"."ID" FROM "B")`},
+	})
+}
@@ -7,12 +7,13 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"time"
 )
 
 var (
 	ConfigPath string
 	_Configs   map[string]*Config
-	_Locker    sync.Mutex
+	_Locker    sync.RWMutex
 )
 
 type SettingMap map[string]string
@@ -38,50 +39,142 @@ func (this SettingMap) Int(key string, defaultValue int) int {
 	return defaultValue
 }
 
-// database settings
+// Duration parses value as time.Duration, like "30s" or "5m", value without unit is treated as seconds.
+func (this SettingMap) Duration(key string, defaultValue time.Duration) time.Duration {
+	v, ok := this[key]
+	if ok {
+		if i, err := strconv.Atoi(v); err == nil {
+			return time.Duration(i) * time.Second
+		}
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+
+	return defaultValue
+}
+
+func (this SettingMap) Bool(key string, defaultValue bool) bool {
+	v, ok := this[key]
+	if ok {
+		b, err := strconv.ParseBool(v)
+		if err == nil {
+			return b
+		}
+	}
+
+	return defaultValue
+}
+
+// database settings, it can be loaded from database.sql.conf file or created directly, like:
+//
+//	cfg := &gsd.Config{
+//		Name:     "Test",
+//		Provider: "mysql",
+//		Settings: gsd.SettingMap{"ConnString": "user:password@tcp(localhost:3306)/Test"},
+//	}
+//
+// Driver defaults to Provider if it is empty. Supported settings are:
+//
+//	ConnString        connection string passed to the driver, required
+//	MaxOpenConns      maximum number of open connections
+//	MaxIdleConns      maximum number of idle connections
+//	ConnMaxLifetime   maximum time a connection may be reused, like "30m", number without unit means seconds
+//	ConnMaxIdleTime   maximum time a connection may be idle
+//	PingOnOpen        ping the database when opening it, default is false
+//	PingRetries       retry times if ping fails, default is 3
+//	PingBackoff       wait time before the first retry, it doubles for each retry, default is 1s
+//	PingTimeout       timeout of each ping, default is 5s
+//	ReloadGracePeriod wait time before closing the old connection pool when the database is reloaded, default is 1m
+//	InitStatements    statements executed on every new connection, separated by ';'
+//	BatchSize         max rows of a batch insert statement, default is 1000
+//	ReplicaPolicy     how to choose a replica for reading: round-robin(default), random or least-conn
+//	StmtCacheSize     max count of cached prepared statements of each connection pool, default is 0(disabled)
 type Config struct {
 	Name     string
 	Provider string
 	Driver   string
 	Settings SettingMap
+	// Replicas are connection strings of read-only replicas, see Database.Select
+	Replicas []string
 }
 
-// GetConfig return configuration of specific database in database.sql.conf file
+// GetConfig return configuration of specific database in database.sql.conf file,
+// ${ENV_VAR} and ${scheme:key} references in setting values are resolved, see SecretResolver.
 func GetConfig(name string) (cfg *Config, err error) {
+	_Locker.Lock()
 	if _Configs == nil {
-		_Locker.Lock()
-		defer _Locker.Unlock()
+		_Configs, err = loadConfigFile()
+	}
+	c, ok := _Configs[name]
+	_Locker.Unlock()
 
-		if _Configs == nil {
-			if ConfigPath == "" {
-				err = fmt.Errorf("You must set [ConfigPath] first for locating databases.")
-			}
+	if err != nil {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("cannot find the configuration of database [%s]", name)
+		return
+	}
 
-			var file *os.File
-			file, err = os.Open(ConfigPath)
-			if err != nil {
-				return
-			}
-			defer file.Close()
+	// references in settings are resolved every time without lock, so changed secrets can be picked up,
+	// and slow resolvers don't block opening other databases. c is never modified after loading.
+	return c.resolve()
+}
 
-			decoder := xml.NewDecoder(file)
-			_Configs, err = loadConfig(decoder)
-			if err != nil {
-				return
-			}
-		}
+// resolve returns a copy of this with all references in settings and replicas resolved
+func (this *Config) resolve() (cfg *Config, err error) {
+	cfg = &Config{Name: this.Name, Provider: this.Provider, Driver: this.Driver}
+	if cfg.Settings, err = resolveSettings(this.Settings); err != nil {
+		return nil, err
 	}
 
-	var ok bool
-	cfg, ok = _Configs[name]
-	if !ok {
-		err = fmt.Errorf("cannot find the configuration of database [%s]", name)
+	for i, r := range this.Replicas {
+		if r, err = resolveValue(r); err != nil {
+			return nil, fmt.Errorf("failed to resolve replica [%d]: %v", i, err)
+		}
+		cfg.Replicas = append(cfg.Replicas, r)
 	}
 	return
 }
 
-func loadConfig(decoder *xml.Decoder) (configs map[string]*Config, err error) {
+// equal reports whether c has the same provider, driver, settings and replicas with this
+func (this *Config) equal(c *Config) bool {
+	if this.Provider != c.Provider || this.Driver != c.Driver || len(this.Settings) != len(c.Settings) || len(this.Replicas) != len(c.Replicas) {
+		return false
+	}
+
+	for i, r := range this.Replicas {
+		if c.Replicas[i] != r {
+			return false
+		}
+	}
+
+	for k, v := range this.Settings {
+		if cv, ok := c.Settings[k]; !ok || cv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func loadConfigFile() (configs map[string]*Config, err error) {
+	if ConfigPath == "" {
+		return nil, fmt.Errorf("You must set [ConfigPath] first for locating databases.")
+	}
+
+	file, err := os.Open(ConfigPath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	return LoadConfig(file, configFormat(ConfigPath))
+}
+
+func loadXmlConfig(r io.Reader) (configs map[string]*Config, err error) {
 	configs = make(map[string]*Config)
+	decoder := xml.NewDecoder(r)
 
 	var (
 		t     xml.Token
@@ -114,6 +207,12 @@ func loadConfig(decoder *xml.Decoder) (configs map[string]*Config, err error) {
 						cfg.Driver = attr.Value
 					}
 				}
+			case "replica":
+				for _, attr := range token.Attr {
+					if attr.Name.Local == "value" {
+						cfg.Replicas = append(cfg.Replicas, attr.Value)
+					}
+				}
 			case "setting":
 				for _, attr := range token.Attr {
 					switch attr.Name.Local {
@@ -127,9 +226,6 @@ func loadConfig(decoder *xml.Decoder) (configs map[string]*Config, err error) {
 			}
 		case xml.EndElement:
 			if token.Name.Local == "database" {
-				if cfg.Driver == "" {
-					cfg.Driver = cfg.Provider
-				}
 				configs[cfg.Name] = cfg
 			}
 		}
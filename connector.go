@@ -0,0 +1,93 @@
+package gsd
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strings"
+)
+
+/********** initConnector **********/
+
+// initConnector creates connections with driver and executes init statements on each of them
+type initConnector struct {
+	driver    driver.Driver
+	connector driver.Connector // connector of the driver, nil if the driver doesn't implement driver.DriverContext
+	dsn       string
+	stmts     []string
+}
+
+// newInitConnector creates the connector of driver once, so dsn isn't parsed for every connection
+func newInitConnector(d driver.Driver, dsn string, stmts []string) (*initConnector, error) {
+	c := &initConnector{
+		driver: d,
+		dsn:    dsn,
+		stmts:  stmts,
+	}
+	if dc, ok := d.(driver.DriverContext); ok {
+		var err error
+		if c.connector, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (this *initConnector) Connect(ctx context.Context) (conn driver.Conn, err error) {
+	if this.connector != nil {
+		conn, err = this.connector.Connect(ctx)
+	} else {
+		conn, err = this.driver.Open(this.dsn)
+	}
+	if err != nil {
+		return
+	}
+
+	for _, stmt := range this.stmts {
+		if err = execConn(ctx, conn, stmt); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return
+}
+
+func (this *initConnector) Driver() driver.Driver {
+	return this.driver
+}
+
+// Close closes connector of the driver if it needs, it is called by sql.DB.Close
+func (this *initConnector) Close() error {
+	if c, ok := this.connector.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func execConn(ctx context.Context, conn driver.Conn, query string) error {
+	if e, ok := conn.(driver.ExecerContext); ok {
+		_, err := e.ExecContext(ctx, query, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(nil)
+	return err
+}
+
+// splitStatements splits statements separated by ';' and drops empty ones
+func splitStatements(s string) (stmts []string) {
+	for _, stmt := range strings.Split(s, ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return
+}
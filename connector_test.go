@@ -0,0 +1,69 @@
+package gsd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInitStatements(t *testing.T) {
+	s := newTestServer(t, "init")
+	db, err := OpenConfig(&Config{Name: "init", Provider: "sqlite", Driver: "gsdtest", Settings: SettingMap{
+		"ConnString":     "init",
+		"InitStatements": "SET A=1; ;SET B=2;",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// hold a transaction, so the next query needs another connection
+	err = db.Transact(func(tx Transaction) error {
+		_, err := db.Execute("SELECT 1").Result()
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := s.Count(func() int { return s.conns }); n != 2 {
+		t.Fatalf("expect 2 connections, got %d", n)
+	}
+	// one is opened by sql.Open, and the other one is shared by connections of initConnector
+	if n := s.Count(func() int { return s.connectors }); n != 2 {
+		t.Errorf("driver connector should be opened once for connections, got %d", n-1)
+	}
+	want := []string{"SET A=1", "SET B=2", "SET A=1", "SET B=2", "SELECT 1"}
+	if got := s.Queries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got queries %v, want %v", got, want)
+	}
+}
+
+func TestPingTimeout(t *testing.T) {
+	s := newTestServer(t, "ping")
+	pings := 0
+	s.ping = func(c context.Context) error {
+		pings++
+		<-c.Done()
+		return c.Err()
+	}
+
+	start := time.Now()
+	_, err := OpenConfig(&Config{Name: "ping", Provider: "sqlite", Driver: "gsdtest", Settings: SettingMap{
+		"ConnString":  "ping",
+		"PingOnOpen":  "true",
+		"PingRetries": "1",
+		"PingBackoff": "10ms",
+		"PingTimeout": "20ms",
+	}})
+	if err == nil {
+		t.Fatal("expect error of ping")
+	}
+	if pings != 2 {
+		t.Errorf("expect 2 pings, got %d", pings)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("ping should time out, took %v", d)
+	}
+}
@@ -1,55 +1,121 @@
 package gsd
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"sync"
+	"time"
 )
 
-var _Databases map[string]*Database = make(map[string]*Database)
+var (
+	_Databases    map[string]*Database = make(map[string]*Database)
+	_ReloadLocker sync.Mutex
+)
 
 /********** executor **********/
 
 type executor interface {
-	Exec(query string, args ...interface{}) (sql.Result, error)
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	QueryRow(query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 /********** Database **********/
 
 type Database struct {
-	db *sql.DB
-	b  builder
+	name   string
+	locker sync.RWMutex
+	p      *pool
+}
+
+func (this *Database) pool() *pool {
+	this.locker.RLock()
+	p := this.p
+	this.locker.RUnlock()
+	return p
 }
 
 func (this *Database) Insert(table string) InsertClause {
-	return newInsertContext(this.db, this.b, &insertInfo{table: table})
+	p := this.pool()
+	return newInsertContext(p.primary(), p.b, &InsertInfo{table: table, batch: p.batch})
 }
 
 func (this *Database) Delete(table string) DeleteClause {
-	return newDeleteContext(this.db, this.b, &deleteInfo{table: table})
+	p := this.pool()
+	return newDeleteContext(p.primary(), p.b, &DeleteInfo{table: table})
 }
 
 func (this *Database) Update(table string) UpdateClause {
-	return newUpdateContext(this.db, this.b, &updateInfo{table: table})
+	p := this.pool()
+	return newUpdateContext(p.primary(), p.b, &UpdateInfo{table: table})
 }
 
+// Upsert inserts a row, or updates it if a row with the same keys exists
+func (this *Database) Upsert(table string) UpsertClause {
+	p := this.pool()
+	return newUpsertContext(p.primary(), p.b, &UpsertInfo{table: table})
+}
+
+// Select reads data from a replica if the database has any, use Primary().Select to read from the primary.
 func (this *Database) Select(columns *Columns) SelectClause {
-	return newSelectContext(this.db, this.b, &selectInfo{columns: columns.columns, distinct: columns.distinct})
+	p := this.pool()
+	return newSelectContext(p.reader(), p.b, &SelectInfo{columns: columns.columns, distinct: columns.distinct})
+}
+
+// Compound starts a compound select action with q, q must be a select clause, like:
+//
+//	db.Compound(q1).UnionAll(q2).OrderBy(s).Limit(0, 10).Rows()
+//
+// Like Select, it reads data from a replica if the database has any.
+func (this *Database) Compound(q interface{}) CompoundClause {
+	p := this.pool()
+	return newCompoundContext(p.reader(), p.b, &CompoundInfo{queries: []interface{}{q}})
 }
 
+// With starts a select action with a common table expression, q must be a select or compound clause,
+// the cte can be used as a table by name, like:
+//
+//	db.With("top", q).Select(gsd.T("top").C("ID")).From(gsd.T("top")).Rows()
+//
+// Like Select, it reads data from a replica if the database has any.
+func (this *Database) With(name string, q interface{}) WithClause {
+	p := this.pool()
+	return newWithContext(p.reader(), p.b).With(name, q)
+}
+
+// WithRecursive is like With, but the cte can reference itself.
+func (this *Database) WithRecursive(name string, q interface{}) WithClause {
+	p := this.pool()
+	return newWithContext(p.reader(), p.b).WithRecursive(name, q)
+}
+
+// Execute runs query on the primary, but Row and Rows of it read data from a replica if the database has any.
 func (this *Database) Execute(query string, args ...interface{}) ExecuteClause {
-	return newExecuteContext(this.db, query, args)
+	p := this.pool()
+	return newExecuteContext(p.primary(), p.reader(), query, args)
+}
+
+// Primary returns a session whose queries all go to the primary, it is useful for reading data just written.
+func (this *Database) Primary() Session {
+	return &primarySession{db: this}
 }
 
 // Transact begin a transaction, the transaction will automatic Commit or Rollback according to return value of handler
 func (this *Database) Transact(f func(tx Transaction) error) (err error) {
-	trans, err := this.db.Begin()
+	return this.TransactContext(context.Background(), nil, f)
+}
+
+// TransactContext is like Transact, but the transaction is started with ctx and opts,
+// it is rolled back if ctx is done before committing.
+func (this *Database) TransactContext(ctx context.Context, opts *sql.TxOptions, f func(tx Transaction) error) (err error) {
+	p := this.pool()
+	trans, err := p.db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
-	tx := newTransaction(trans, this.b)
+	tx := newTransaction(trans, p.transaction(trans), p.b, p.batch)
 
 	defer func() {
 		if e := recover(); e != nil {
@@ -69,7 +135,10 @@ func (this *Database) Transact(f func(tx Transaction) error) (err error) {
 // open database
 func Open(name string) (db *Database, err error) {
 	var ok bool
-	if db, ok = _Databases[name]; ok {
+	_Locker.RLock()
+	db, ok = _Databases[name]
+	_Locker.RUnlock()
+	if ok {
 		return
 	}
 
@@ -78,58 +147,241 @@ func Open(name string) (db *Database, err error) {
 		return
 	}
 
-	// since the config only need be initialized once, we can reuse the locker for initializing databases
-	_Locker.Lock()
-	defer _Locker.Unlock()
-
-	// double check
-	if db, ok = _Databases[name]; ok {
+	// open without lock, pinging may take a long time
+	var d *Database
+	if d, err = newDatabase(cfg); err != nil {
 		return
 	}
 
-	if db, err = newDatabase(cfg); err == nil {
+	_Locker.Lock()
+	// double check, the database may be opened by another goroutine at the same time
+	if db, ok = _Databases[name]; !ok {
+		db = d
 		_Databases[name] = db
 	}
+	_Locker.Unlock()
+
+	if db != d {
+		d.pool().close()
+	}
+	return
+}
+
+// OpenConfig opens a database with cfg directly instead of database.sql.conf file,
+// the returned database is not registered, so it can't be got by Open.
+func OpenConfig(cfg *Config) (*Database, error) {
+	return newDatabase(cfg)
+}
+
+// Register opens a database with cfg and registers it with cfg.Name, so it can be got by Open later.
+func Register(cfg *Config) error {
+	if cfg.Name == "" {
+		return fmt.Errorf("name of database is not configured")
+	}
+
+	_Locker.RLock()
+	_, ok := _Databases[cfg.Name]
+	_Locker.RUnlock()
+	if ok {
+		return fmt.Errorf("database [%s] is already registered", cfg.Name)
+	}
+
+	// open without lock, pinging may take a long time
+	db, err := newDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	_Locker.Lock()
+	if _, ok = _Databases[cfg.Name]; !ok {
+		_Databases[cfg.Name] = db
+	}
+	_Locker.Unlock()
+
+	if ok {
+		db.pool().close()
+		return fmt.Errorf("database [%s] is already registered", cfg.Name)
+	}
+	return nil
+}
+
+// Close closes connections of the database and removes it from registered databases.
+func (this *Database) Close() error {
+	_Locker.Lock()
+	if _Databases[this.name] == this {
+		delete(_Databases, this.name)
+	}
+	_Locker.Unlock()
+
+	return this.pool().close()
+}
+
+// CloseAll closes all registered databases.
+func CloseAll() (err error) {
+	_Locker.Lock()
+	dbs := _Databases
+	_Databases = make(map[string]*Database)
+	_Locker.Unlock()
+
+	for _, db := range dbs {
+		if e := db.pool().close(); e != nil && err == nil {
+			err = e
+		}
+	}
 	return
 }
 
+/********** primarySession **********/
+
+type primarySession struct {
+	db *Database
+}
+
+func (this *primarySession) Insert(table string) InsertClause {
+	return this.db.Insert(table)
+}
+
+func (this *primarySession) Delete(table string) DeleteClause {
+	return this.db.Delete(table)
+}
+
+func (this *primarySession) Update(table string) UpdateClause {
+	return this.db.Update(table)
+}
+
+func (this *primarySession) Upsert(table string) UpsertClause {
+	return this.db.Upsert(table)
+}
+
+func (this *primarySession) Select(columns *Columns) SelectClause {
+	p := this.db.pool()
+	return newSelectContext(p.primary(), p.b, &SelectInfo{columns: columns.columns, distinct: columns.distinct})
+}
+
+func (this *primarySession) Compound(q interface{}) CompoundClause {
+	p := this.db.pool()
+	return newCompoundContext(p.primary(), p.b, &CompoundInfo{queries: []interface{}{q}})
+}
+
+func (this *primarySession) With(name string, q interface{}) WithClause {
+	p := this.db.pool()
+	return newWithContext(p.primary(), p.b).With(name, q)
+}
+
+func (this *primarySession) WithRecursive(name string, q interface{}) WithClause {
+	p := this.db.pool()
+	return newWithContext(p.primary(), p.b).WithRecursive(name, q)
+}
+
+func (this *primarySession) Execute(query string, args ...interface{}) ExecuteClause {
+	p := this.db.pool()
+	return newExecuteContext(p.primary(), p.primary(), query, args)
+}
+
+/********** lifecycle **********/
+
+// Reload re-reads the config file, registered databases whose configuration changed get new connection pools.
+// Clauses built before reloading still use the old pools, so old pools are closed after ReloadGracePeriod(default is 1m)
+// and then running queries finish. Databases not in config file are left unchanged.
+func Reload() error {
+	// pools are opened without _Locker since pinging may take a long time, so reloads are serialized by another locker
+	_ReloadLocker.Lock()
+	defer _ReloadLocker.Unlock()
+
+	configs, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	_Locker.RLock()
+	dbs := make(map[string]*Database, len(_Databases))
+	for name, db := range _Databases {
+		dbs[name] = db
+	}
+	_Locker.RUnlock()
+
+	// open all changed pools first, so a failed reload leaves databases unchanged
+	pools := make(map[*Database]*pool)
+	for name, db := range dbs {
+		c, ok := configs[name]
+		if !ok {
+			continue
+		}
+
+		var cfg *Config
+		if cfg, err = c.resolve(); err != nil {
+			break
+		}
+		if cfg.equal(db.pool().cfg) {
+			continue
+		}
+
+		var p *pool
+		if p, err = newPool(cfg); err != nil {
+			break
+		}
+		pools[db] = p
+	}
+	if err != nil {
+		for _, p := range pools {
+			p.close()
+		}
+		return err
+	}
+
+	_Locker.Lock()
+	defer _Locker.Unlock()
+
+	_Configs = configs
+	for db, p := range pools {
+		if _Databases[db.name] != db {
+			// closed while reloading
+			p.close()
+			continue
+		}
+
+		db.locker.Lock()
+		old := db.p
+		db.p = p
+		db.locker.Unlock()
+
+		// clauses built before reloading may still hold executors of the old pool, close it after they are done
+		time.AfterFunc(old.cfg.Settings.Duration("ReloadGracePeriod", time.Minute), func() {
+			old.close()
+		})
+	}
+	return nil
+}
+
 func newDatabase(cfg *Config) (*Database, error) {
-	var (
-		db  *Database
-		err error
-	)
-
-	db = &Database{}
-	switch cfg.Provider {
-	case "mssql":
-		db.b = &mssqlBuilder{}
-	case "mssql2005":
-		db.b = &mssql2005Builder{}
-	case "mysql":
-		db.b = &mysqlBuilder{}
-	default:
-		return nil, fmt.Errorf("not supported database provider: %s", cfg.Provider)
-	}
-
-	db.db, err = newDB(cfg)
+	p, err := newPool(cfg)
 	if err != nil {
 		return nil, err
 	}
-
-	return db, nil
+	return &Database{name: cfg.Name, p: p}, nil
 }
 
-func newDB(cfg *Config) (db *sql.DB, err error) {
-	connStr, ok := cfg.Settings["ConnString"]
-	if !ok {
-		return nil, fmt.Errorf("connection string of database [%s] is not configured", cfg.Name)
+func newDB(cfg *Config, connStr string) (db *sql.DB, err error) {
+	driver := cfg.Driver
+	if driver == "" {
+		driver = cfg.Provider
 	}
 
-	db, err = sql.Open(cfg.Driver, connStr)
+	db, err = sql.Open(driver, connStr)
 	if err != nil {
 		return
 	}
 
+	if stmts := splitStatements(cfg.Settings.String("InitStatements", "")); len(stmts) > 0 {
+		// reopen with a connector which runs the statements on every new connection
+		c, e := newInitConnector(db.Driver(), connStr, stmts)
+		db.Close()
+		if e != nil {
+			return nil, e
+		}
+		db = sql.OpenDB(c)
+	}
+
 	maxOpenConns := cfg.Settings.Int("MaxOpenConns", 0)
 	maxIdleConns := cfg.Settings.Int("MaxIdleConns", 0)
 	if maxOpenConns > 0 {
@@ -138,5 +390,36 @@ func newDB(cfg *Config) (db *sql.DB, err error) {
 	if maxIdleConns > 0 {
 		db.SetMaxIdleConns(maxIdleConns)
 	}
+	if d := cfg.Settings.Duration("ConnMaxLifetime", 0); d > 0 {
+		db.SetConnMaxLifetime(d)
+	}
+	if d := cfg.Settings.Duration("ConnMaxIdleTime", 0); d > 0 {
+		db.SetConnMaxIdleTime(d)
+	}
+
+	if cfg.Settings.Bool("PingOnOpen", false) {
+		retries := cfg.Settings.Int("PingRetries", 3)
+		backoff := cfg.Settings.Duration("PingBackoff", time.Second)
+		timeout := cfg.Settings.Duration("PingTimeout", 5*time.Second)
+		if err = ping(db, retries, backoff, timeout); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to ping database [%s]: %v", cfg.Name, err)
+		}
+	}
 	return
 }
+
+// ping pings db until it succeeds or all retries fail, every ping fails after timeout,
+// the wait time between retries doubles every time
+func ping(db *sql.DB, retries int, backoff, timeout time.Duration) (err error) {
+	for i := 0; ; i++ {
+		c, cancel := context.WithTimeout(context.Background(), timeout)
+		err = db.PingContext(c)
+		cancel()
+		if err == nil || i >= retries {
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,135 @@
+package gsd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useConfigFile sets ConfigPath to a temp json file with content, it is restored after test t
+func useConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "database.json")
+	writeConfigFile(t, path, content)
+
+	oldPath := ConfigPath
+	_Locker.Lock()
+	ConfigPath, _Configs = path, nil
+	_Locker.Unlock()
+
+	t.Cleanup(func() {
+		CloseAll()
+		_Locker.Lock()
+		ConfigPath, _Configs = oldPath, nil
+		_Locker.Unlock()
+	})
+	return path
+}
+
+func writeConfigFile(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	a, b := newTestServer(t, "reload-a"), newTestServer(t, "reload-b")
+	const config = `{"databases": [{"name": "reload", "provider": "sqlite", "driver": "gsdtest",
+	"settings": {"ConnString": "%s", "ReloadGracePeriod": "10ms"}}]}`
+	path := useConfigFile(t, fmt.Sprintf(config, "reload-a"))
+
+	db, err := Open("reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Execute("SELECT 1").Result(); err != nil {
+		t.Fatal(err)
+	}
+
+	// unchanged databases keep their pools
+	old := db.pool()
+	if err = Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if db.pool() != old {
+		t.Fatal("pool should not be replaced if configuration isn't changed")
+	}
+
+	writeConfigFile(t, path, fmt.Sprintf(config, "reload-b"))
+	if err = Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if db.pool() == old {
+		t.Fatal("pool should be replaced after configuration is changed")
+	}
+	if _, err = db.Execute("SELECT 2").Result(); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Queries(); len(got) != 1 || got[0] != "SELECT 1" {
+		t.Errorf("unexpected queries of the old database: %v", got)
+	}
+	if got := b.Queries(); len(got) != 1 || got[0] != "SELECT 2" {
+		t.Errorf("unexpected queries of the new database: %v", got)
+	}
+
+	// the old pool is closed after ReloadGracePeriod
+	deadline := time.Now().Add(time.Second)
+	for old.db.Ping() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("old pool should be closed after grace period")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloadFailed(t *testing.T) {
+	newTestServer(t, "reload-failed")
+	const config = `{"databases": [{"name": "reload", "provider": "sqlite", "driver": "gsdtest",
+	"settings": {"ConnString": "reload-failed", "ReplicaPolicy": "%s"}}]}`
+	path := useConfigFile(t, fmt.Sprintf(config, "random"))
+
+	db, err := Open("reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed reload leaves databases unchanged
+	old := db.pool()
+	writeConfigFile(t, path, fmt.Sprintf(config, "unknown"))
+	if err = Reload(); err == nil {
+		t.Fatal("expect error of invalid replica policy")
+	}
+	if db.pool() != old {
+		t.Error("pool should not be replaced if reloading failed")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	newTestServer(t, "register")
+	cfg := &Config{Name: "register", Provider: "sqlite", Driver: "gsdtest", Settings: SettingMap{"ConnString": "register"}}
+	t.Cleanup(func() { CloseAll() })
+
+	if err := Register(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(cfg); err == nil {
+		t.Fatal("expect error of duplicate registration")
+	}
+
+	db, err := Open("register")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Execute("SELECT 1").Result(); err == nil {
+		t.Error("expect error after closing")
+	}
+
+	// closed database is removed, so it can be registered again
+	if err = Register(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
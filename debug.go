@@ -21,8 +21,8 @@ func Debug(ctx interface{}) (sql string, args []interface{}, err error) {
 }
 
 func (this *deleteContext) Debug() (sql string, args []interface{}, err error) {
-	ctx := newBuildContext()
-	err = this.b.BuildDelete(ctx, this.info)
+	ctx := newBuildContext(this.b)
+	err = ctx.check(this.b.BuildDelete(ctx, this.info))
 	if err == nil {
 		sql, args = ctx.GetSql(), ctx.GetParams()
 	}
@@ -30,8 +30,17 @@ func (this *deleteContext) Debug() (sql string, args []interface{}, err error) {
 }
 
 func (this *insertContext) Debug() (sql string, args []interface{}, err error) {
-	ctx := newBuildContext()
-	err = this.b.BuildInsert(ctx, this.info)
+	ctx := newBuildContext(this.b)
+	err = ctx.check(this.b.BuildInsert(ctx, this.info))
+	if err == nil {
+		sql, args = ctx.GetSql(), ctx.GetParams()
+	}
+	return
+}
+
+func (this *upsertContext) Debug() (sql string, args []interface{}, err error) {
+	ctx := newBuildContext(this.b)
+	err = ctx.check(this.b.BuildUpsert(ctx, this.info))
 	if err == nil {
 		sql, args = ctx.GetSql(), ctx.GetParams()
 	}
@@ -39,8 +48,17 @@ func (this *insertContext) Debug() (sql string, args []interface{}, err error) {
 }
 
 func (this *updateContext) Debug() (sql string, args []interface{}, err error) {
-	ctx := newBuildContext()
-	err = this.b.BuildUpdate(ctx, this.info)
+	ctx := newBuildContext(this.b)
+	err = ctx.check(this.b.BuildUpdate(ctx, this.info))
+	if err == nil {
+		sql, args = ctx.GetSql(), ctx.GetParams()
+	}
+	return
+}
+
+func (this *compoundContext) Debug() (sql string, args []interface{}, err error) {
+	ctx := newBuildContext(this.b)
+	err = ctx.check(this.b.BuildCompound(ctx, this.info))
 	if err == nil {
 		sql, args = ctx.GetSql(), ctx.GetParams()
 	}
@@ -48,8 +66,28 @@ func (this *updateContext) Debug() (sql string, args []interface{}, err error) {
 }
 
 func (this *selectContext) Debug() (sql string, args []interface{}, err error) {
-	ctx := newBuildContext()
-	err = this.b.BuildSelect(ctx, this.info)
+	ctx := newBuildContext(this.b)
+	err = ctx.check(this.b.BuildSelect(ctx, this.info))
+	if err == nil {
+		sql, args = ctx.GetSql(), ctx.GetParams()
+	}
+	return
+}
+
+// Debug returns the first statement of the batch
+func (this *batchInsertContext) Debug() (sql string, args []interface{}, err error) {
+	if err = this.prepare(); err != nil {
+		return
+	}
+
+	chunks, err := this.chunks()
+	if err != nil {
+		return
+	}
+
+	ctx := newBuildContext(this.b)
+	info := &InsertInfo{table: this.info.table, columns: this.info.columns, rows: chunks[0]}
+	err = ctx.check(this.b.BuildInsert(ctx, info))
 	if err == nil {
 		sql, args = ctx.GetSql(), ctx.GetParams()
 	}
@@ -1,21 +1,27 @@
 package gsd
 
-/********** deleteInfo **********/
+import (
+	"context"
+)
 
-type deleteInfo struct {
-	table string
-	where Filters
+/********** DeleteInfo **********/
+
+type DeleteInfo struct {
+	table     string
+	joins     []*joiner
+	where     Filters
+	returning []string
 }
 
 /********** deleteContext **********/
 
 type deleteContext struct {
 	exe  executor
-	b    builder
-	info *deleteInfo
+	b    Dialect
+	info *DeleteInfo
 }
 
-func newDeleteContext(exe executor, b builder, info *deleteInfo) *deleteContext {
+func newDeleteContext(exe executor, b Dialect, info *DeleteInfo) *deleteContext {
 	return &deleteContext{
 		exe:  exe,
 		b:    b,
@@ -23,16 +29,58 @@ func newDeleteContext(exe executor, b builder, info *deleteInfo) *deleteContext
 	}
 }
 
-func (this *deleteContext) Where(f Filters) ResultClause {
+// Join joins table t, so rows can be filtered by columns of t
+func (this *deleteContext) Join(t Table, on Filters) DeleteClause {
+	this.info.joins = append(this.info.joins, &joiner{jt: JOIN_INNER, t: t, on: on})
+	return this
+}
+
+// LeftJoin joins table t with LEFT JOIN, it is not supported by PostgreSQL
+func (this *deleteContext) LeftJoin(t Table, on Filters) DeleteClause {
+	this.info.joins = append(this.info.joins, &joiner{jt: JOIN_LEFT, t: t, on: on})
+	return this
+}
+
+func (this *deleteContext) Where(f Filters) ReturningClause {
 	this.info.where = f
 	return this
 }
 
+// Returning returns columns of deleted rows instead of the result
+func (this *deleteContext) Returning(cols ...string) RowClause {
+	this.info.returning = cols
+	return this
+}
+
+func (this *deleteContext) Row() Row {
+	return this.RowContext(context.Background())
+}
+
+func (this *deleteContext) Rows() Rows {
+	return this.RowsContext(context.Background())
+}
+
+func (this *deleteContext) RowContext(c context.Context) Row {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildDelete(ctx, this.info))
+	return newBuiltRow(this.exe, c, ctx, err)
+}
+
+func (this *deleteContext) RowsContext(c context.Context) Rows {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildDelete(ctx, this.info))
+	return newBuiltRows(this.exe, c, ctx, err)
+}
+
 func (this *deleteContext) Result() (Result, error) {
-	ctx := newBuildContext()
-	err := this.b.BuildDelete(ctx, this.info)
+	return this.ResultContext(context.Background())
+}
+
+func (this *deleteContext) ResultContext(c context.Context) (Result, error) {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildDelete(ctx, this.info))
 	if err != nil {
 		return nil, err
 	}
-	return this.exe.Exec(ctx.GetSql(), ctx.GetParams()...)
+	return this.exe.ExecContext(c, ctx.GetSql(), ctx.GetParams()...)
 }
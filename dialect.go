@@ -0,0 +1,85 @@
+package gsd
+
+import (
+	"sync"
+)
+
+var (
+	_Dialects = map[string]Dialect{
+		"mysql":     newMysqlBuilder(),
+		"mssql":     newMssqlBuilder(),
+		"mssql2005": newMssql2005Builder(),
+		"sqlite":    newSqliteBuilder(),
+		"postgres":  newPostgresBuilder(),
+	}
+	_DialectLocker sync.RWMutex
+)
+
+// Dialect generates SQL for a specific type of database.
+//
+// The Build methods can be provided by embedding a *Builder, so a custom dialect normally only implements the hooks:
+//
+//	type tidbDialect struct {
+//		*gsd.Builder
+//	}
+//
+//	func newTidbDialect() *tidbDialect {
+//		d := &tidbDialect{}
+//		d.Builder = gsd.NewBuilder(d)
+//		d.NoRecursive = true
+//		d.Returning = gsd.RETURNING_NONE
+//		return d
+//	}
+//
+// A Build method can also be overridden with the exported parts of Builder, like paging with TOP:
+//
+//	func (this *sybaseDialect) BuildSelect(ctx *gsd.BuildContext, info *gsd.SelectInfo) error {
+//		if err := this.BuildWith(ctx, info); err != nil {
+//			return err
+//		}
+//		ctx.AppendSql("SELECT ")
+//		if info.Take() > 0 {
+//			ctx.AppendSqlF("TOP %d ", info.Skip()+info.Take())
+//		}
+//		this.BuildColumns(ctx, info)
+//		if err := this.BuildBody(ctx, info); err != nil {
+//			return err
+//		}
+//		this.BuildOrders(ctx, info)
+//		return nil
+//	}
+type Dialect interface {
+	BuildSelect(ctx *BuildContext, info *SelectInfo) error
+	BuildInsert(ctx *BuildContext, info *InsertInfo) error
+	BuildUpdate(ctx *BuildContext, info *UpdateInfo) error
+	BuildDelete(ctx *BuildContext, info *DeleteInfo) error
+	BuildUpsert(ctx *BuildContext, info *UpsertInfo) error
+	BuildCompound(ctx *BuildContext, info *CompoundInfo) error
+
+	// Quote returns quoted identifier, like `ID` or [ID]
+	Quote(name string) string
+	// Placeholder returns placeholder of the parameter at position index(starts from 1), like ? or $1
+	Placeholder(index int) string
+	// Page returns paging clause appended to select action, like " LIMIT 10,20"
+	Page(skip, take int32) string
+	// MaxParams returns maximum count of parameters in a statement, 0 means no limit
+	MaxParams() int
+}
+
+// RegisterDialect makes a dialect available by the provider name, it replaces the existing one with the same name.
+func RegisterDialect(provider string, d Dialect) {
+	if d == nil {
+		panic("gsd: RegisterDialect dialect is nil")
+	}
+
+	_DialectLocker.Lock()
+	_Dialects[provider] = d
+	_DialectLocker.Unlock()
+}
+
+func getDialect(provider string) (d Dialect, ok bool) {
+	_DialectLocker.RLock()
+	d, ok = _Dialects[provider]
+	_DialectLocker.RUnlock()
+	return
+}
@@ -0,0 +1,209 @@
+package gsd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+func init() {
+	sql.Register("gsdtest", testDriver{})
+}
+
+var (
+	_TestServers      = map[string]*testServer{}
+	_TestServerLocker sync.Mutex
+)
+
+// testServer records what a fake database receives, it is chosen by dsn of the gsdtest driver
+type testServer struct {
+	locker     sync.Mutex
+	connectors int      // count of driver connectors
+	conns      int      // count of opened connections
+	prepares   int      // count of prepared statements
+	stmts      int      // count of statements not closed
+	queries    []string // executed queries
+	ping       func(c context.Context) error
+}
+
+// newTestServer registers a fake database with dsn for test t
+func newTestServer(t *testing.T, dsn string) *testServer {
+	s := &testServer{}
+	_TestServerLocker.Lock()
+	_TestServers[dsn] = s
+	_TestServerLocker.Unlock()
+
+	t.Cleanup(func() {
+		_TestServerLocker.Lock()
+		delete(_TestServers, dsn)
+		_TestServerLocker.Unlock()
+	})
+	return s
+}
+
+func getTestServer(dsn string) (*testServer, error) {
+	_TestServerLocker.Lock()
+	defer _TestServerLocker.Unlock()
+
+	if s, ok := _TestServers[dsn]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("test server [%s] is not found", dsn)
+}
+
+// Queries returns a copy of executed queries
+func (this *testServer) Queries() []string {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	return append([]string(nil), this.queries...)
+}
+
+// Count returns value of a counter with the locker held
+func (this *testServer) Count(f func() int) int {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	return f()
+}
+
+func (this *testServer) exec(query string) {
+	this.locker.Lock()
+	this.queries = append(this.queries, query)
+	this.locker.Unlock()
+}
+
+/********** testDriver **********/
+
+type testDriver struct{}
+
+func (testDriver) Open(dsn string) (driver.Conn, error) {
+	c, err := testDriver{}.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+func (testDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	s, err := getTestServer(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	s.locker.Lock()
+	s.connectors++
+	s.locker.Unlock()
+	return &testConnector{s: s}, nil
+}
+
+type testConnector struct {
+	s *testServer
+}
+
+func (this *testConnector) Connect(context.Context) (driver.Conn, error) {
+	this.s.locker.Lock()
+	this.s.conns++
+	this.s.locker.Unlock()
+	return &testConn{s: this.s}, nil
+}
+
+func (this *testConnector) Driver() driver.Driver {
+	return testDriver{}
+}
+
+/********** testConn **********/
+
+type testConn struct {
+	s *testServer
+}
+
+func (this *testConn) Prepare(query string) (driver.Stmt, error) {
+	this.s.locker.Lock()
+	this.s.prepares++
+	this.s.stmts++
+	this.s.locker.Unlock()
+	return &testStmt{s: this.s, query: query}, nil
+}
+
+func (this *testConn) Close() error {
+	return nil
+}
+
+func (this *testConn) Begin() (driver.Tx, error) {
+	return testTx{}, nil
+}
+
+func (this *testConn) Ping(c context.Context) error {
+	if this.s.ping != nil {
+		return this.s.ping(c)
+	}
+	return nil
+}
+
+func (this *testConn) ExecContext(c context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	this.s.exec(query)
+	return driver.RowsAffected(1), nil
+}
+
+func (this *testConn) QueryContext(c context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	this.s.exec(query)
+	return &testRows{}, nil
+}
+
+type testTx struct{}
+
+func (testTx) Commit() error   { return nil }
+func (testTx) Rollback() error { return nil }
+
+/********** testStmt **********/
+
+type testStmt struct {
+	s     *testServer
+	query string
+}
+
+func (this *testStmt) Close() error {
+	this.s.locker.Lock()
+	this.s.stmts--
+	this.s.locker.Unlock()
+	return nil
+}
+
+func (this *testStmt) NumInput() int {
+	return -1
+}
+
+func (this *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	this.s.exec(this.query)
+	return driver.RowsAffected(1), nil
+}
+
+func (this *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	this.s.exec(this.query)
+	return &testRows{}, nil
+}
+
+// testRows has one row with column ID=1
+type testRows struct {
+	done bool
+}
+
+func (this *testRows) Columns() []string {
+	return []string{"ID"}
+}
+
+func (this *testRows) Close() error {
+	return nil
+}
+
+func (this *testRows) Next(dest []driver.Value) error {
+	if this.done {
+		return io.EOF
+	}
+	this.done = true
+	dest[0] = int64(1)
+	return nil
+}
@@ -1,36 +1,56 @@
 package gsd
 
+import (
+	"context"
+)
+
 /********** executeContext **********/
 
 type executeContext struct {
-	exe   executor
-	query string
-	args  []interface{}
+	exe    executor
+	reader executor // executor for Row and Rows
+	query  string
+	args   []interface{}
 }
 
-func newExecuteContext(exe executor, query string, args []interface{}) *executeContext {
+func newExecuteContext(exe, reader executor, query string, args []interface{}) *executeContext {
 	return &executeContext{
-		exe:   exe,
-		query: query,
-		args:  args,
+		exe:    exe,
+		reader: reader,
+		query:  query,
+		args:   args,
 	}
 }
 
 func (this *executeContext) Result() (ExecuteResult, error) {
-	return this.exe.Exec(this.query, this.args...)
+	return this.ResultContext(context.Background())
+}
+
+func (this *executeContext) ResultContext(ctx context.Context) (ExecuteResult, error) {
+	return this.exe.ExecContext(ctx, this.query, this.args...)
 }
 
 func (this *executeContext) Row() Row {
+	return this.RowContext(context.Background())
+}
+
+func (this *executeContext) Rows() Rows {
+	return this.RowsContext(context.Background())
+}
+
+func (this *executeContext) RowContext(ctx context.Context) Row {
 	return &row{
-		exe:  this.exe,
+		exe:  this.reader,
+		ctx:  ctx,
 		sql:  this.query,
 		args: this.args,
 	}
 }
 
-func (this *executeContext) Rows() Rows {
+func (this *executeContext) RowsContext(ctx context.Context) Rows {
 	return &rows{
-		exe:  this.exe,
+		exe:  this.reader,
+		ctx:  ctx,
 		sql:  this.query,
 		args: this.args,
 	}
@@ -0,0 +1,221 @@
+package gsd
+
+import (
+	"strconv"
+)
+
+/********** Expr **********/
+
+// Expr is a typed expression, it is rendered by dialects with quoted identifiers and bound parameters,
+// so it can be used in SELECT, WHERE, GROUP BY, HAVING and ORDER BY, like:
+//
+//	gsd.Sum(gsd.Multiply(gsd.Col(t, "PRICE"), gsd.Col(t, "COUNT")))
+//
+// Arguments of type interface{} are used directly if they are Expr, otherwise they are bound as parameters.
+type Expr interface {
+	expr()
+}
+
+// Col returns a column reference, t can be nil if the column doesn't need table prefix
+func Col(t Table, col string) Expr {
+	return &colExpr{table: t, column: col}
+}
+
+// Val returns a value expression, v is bound as a parameter
+func Val(v interface{}) Expr {
+	return &valueExpr{value: v}
+}
+
+// Func returns a call of function name, like: Func("LOWER", Col(t, "NAME"))
+func Func(name string, args ...interface{}) Expr {
+	return &funcExpr{name: name, args: toExprs(args)}
+}
+
+// CountAll returns COUNT(*)
+func CountAll() Expr {
+	return &funcExpr{name: "COUNT", args: []Expr{&rawExpr{sql: "*"}}}
+}
+
+func Count(e Expr) Expr {
+	return &funcExpr{name: "COUNT", args: []Expr{e}}
+}
+
+// CountDistinct returns COUNT(DISTINCT e)
+func CountDistinct(e Expr) Expr {
+	return &funcExpr{name: "COUNT", distinct: true, args: []Expr{e}}
+}
+
+func Sum(e Expr) Expr {
+	return &funcExpr{name: "SUM", args: []Expr{e}}
+}
+
+func Avg(e Expr) Expr {
+	return &funcExpr{name: "AVG", args: []Expr{e}}
+}
+
+func Min(e Expr) Expr {
+	return &funcExpr{name: "MIN", args: []Expr{e}}
+}
+
+func Max(e Expr) Expr {
+	return &funcExpr{name: "MAX", args: []Expr{e}}
+}
+
+func Coalesce(args ...interface{}) Expr {
+	return &funcExpr{name: "COALESCE", args: toExprs(args)}
+}
+
+// Cast returns CAST(e AS typ), typ is written as is, like: Cast(Col(t, "PRICE"), "DECIMAL(10,2)")
+func Cast(e interface{}, typ string) Expr {
+	return &castExpr{e: toExpr(e), typ: typ}
+}
+
+// Plus returns (a+b)
+func Plus(a, b interface{}) Expr {
+	return &binaryExpr{op: "+", left: toExpr(a), right: toExpr(b)}
+}
+
+// Minus returns (a-b)
+func Minus(a, b interface{}) Expr {
+	return &binaryExpr{op: "-", left: toExpr(a), right: toExpr(b)}
+}
+
+// Multiply returns (a*b)
+func Multiply(a, b interface{}) Expr {
+	return &binaryExpr{op: "*", left: toExpr(a), right: toExpr(b)}
+}
+
+// Divide returns (a/b)
+func Divide(a, b interface{}) Expr {
+	return &binaryExpr{op: "/", left: toExpr(a), right: toExpr(b)}
+}
+
+// RowNumber returns ROW_NUMBER(), it must be used with Over
+func RowNumber() Expr {
+	return &funcExpr{name: "ROW_NUMBER"}
+}
+
+// Rank returns RANK(), it must be used with Over
+func Rank() Expr {
+	return &funcExpr{name: "RANK"}
+}
+
+// DenseRank returns DENSE_RANK(), it must be used with Over
+func DenseRank() Expr {
+	return &funcExpr{name: "DENSE_RANK"}
+}
+
+// Lag returns LAG(e,offset[,def]), it must be used with Over
+func Lag(e Expr, offset int, def ...interface{}) Expr {
+	return &funcExpr{name: "LAG", args: offsetArgs(e, offset, def)}
+}
+
+// Lead returns LEAD(e,offset[,def]), it must be used with Over
+func Lead(e Expr, offset int, def ...interface{}) Expr {
+	return &funcExpr{name: "LEAD", args: offsetArgs(e, offset, def)}
+}
+
+// offsetArgs returns arguments of LAG and LEAD, offset is written as literal since some databases don't accept a parameter
+func offsetArgs(e Expr, offset int, def []interface{}) []Expr {
+	args := []Expr{e, &rawExpr{sql: strconv.Itoa(offset)}}
+	if len(def) > 0 {
+		args = append(args, toExpr(def[0]))
+	}
+	return args
+}
+
+// Over returns e OVER(PARTITION BY ... ORDER BY ...), e is a window or aggregate function,
+// partition and order can be nil, like:
+//
+//	gsd.Over(gsd.RowNumber(), t.G("USER_ID"), t.S(gsd.SORT_DESC, "AMOUNT"))
+func Over(e Expr, partition *Groupers, order *Sorters) Expr {
+	o := &overExpr{e: e}
+	if partition != nil {
+		o.partitions = partition.groupers
+	}
+	if order != nil {
+		o.orders = order.sorters
+	}
+	return o
+}
+
+// toExpr returns v if it is an Expr, otherwise a value expression of v
+func toExpr(v interface{}) Expr {
+	if e, ok := v.(Expr); ok {
+		return e
+	}
+	return &valueExpr{value: v}
+}
+
+func toExprs(values []interface{}) []Expr {
+	exprs := make([]Expr, len(values))
+	for i, v := range values {
+		exprs[i] = toExpr(v)
+	}
+	return exprs
+}
+
+/********** colExpr **********/
+
+type colExpr struct {
+	table  Table
+	column string
+}
+
+func (this *colExpr) expr() {}
+
+/********** valueExpr **********/
+
+type valueExpr struct {
+	value interface{}
+}
+
+func (this *valueExpr) expr() {}
+
+/********** rawExpr **********/
+
+// rawExpr is written as is, it is only used internally, like * of COUNT(*)
+type rawExpr struct {
+	sql string
+}
+
+func (this *rawExpr) expr() {}
+
+/********** funcExpr **********/
+
+type funcExpr struct {
+	name     string
+	distinct bool
+	args     []Expr
+}
+
+func (this *funcExpr) expr() {}
+
+/********** castExpr **********/
+
+type castExpr struct {
+	e   Expr
+	typ string
+}
+
+func (this *castExpr) expr() {}
+
+/********** overExpr **********/
+
+type overExpr struct {
+	e          Expr
+	partitions []*grouper
+	orders     []*sorter
+}
+
+func (this *overExpr) expr() {}
+
+/********** binaryExpr **********/
+
+type binaryExpr struct {
+	op    string
+	left  Expr
+	right Expr
+}
+
+func (this *binaryExpr) expr() {}
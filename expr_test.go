@@ -0,0 +1,102 @@
+package gsd
+
+import (
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u := T("User")
+		cols := C(false).AddX(Func("LOWER", Col(u, "NAME")), "NAME").
+			AddX(Coalesce(Col(u, "NICK"), "none"), "NICK").
+			AddX(Cast(Multiply(Col(u, "PRICE"), Col(u, "QTY")), "DECIMAL(10,2)"), "AMOUNT").
+			AddX(CountDistinct(Col(nil, "CITY")), "CITIES")
+		sc := newSelectContext(nil, d, &SelectInfo{columns: cols.columns})
+		sc.From(u).Where(F().AddX(Plus(Col(u, "AGE"), 1), FILTER_GT, 18))
+		sc.GroupBy(new(Groupers).AddX(Func("LOWER", Col(u, "NAME")))).Having(F().AddX(CountAll(), FILTER_GT, 1))
+		sc.OrderBy(new(Sorters).AddX(SORT_DESC, Divide(Sum(Col(u, "PRICE")), Count(Col(u, "ID")))))
+		return sc
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT LOWER(`User`.`NAME`) AS `NAME`,COALESCE(`User`.`NICK`,?) AS `NICK`,CAST((`User`.`PRICE`*`User`.`QTY`) AS DECIMAL(10,2)) AS `AMOUNT`,COUNT(DISTINCT `CITY`) AS `CITIES` FROM `User` WHERE (`User`.`AGE`+?)>? GROUP BY LOWER(`User`.`NAME`) HAVING COUNT(*)>? ORDER BY (SUM(`User`.`PRICE`)/COUNT(`User`.`ID`)) DESC", args: []interface{}{"none", 1, 18, 1}},
+		"mssql":     {sql: `SELECT LOWER([User].[NAME]) AS [NAME],COALESCE([User].[NICK],?) AS [NICK],CAST(([User].[PRICE]*[User].[QTY]) AS DECIMAL(10,2)) AS [AMOUNT],COUNT(DISTINCT [CITY]) AS [CITIES] FROM [User] WHERE ([User].[AGE]+?)>? GROUP BY LOWER([User].[NAME]) HAVING COUNT(*)>? ORDER BY (SUM([User].[PRICE])/COUNT([User].[ID])) DESC`, args: []interface{}{"none", 1, 18, 1}},
+		"mssql2005": {sql: `SELECT LOWER([User].[NAME]) AS [NAME],COALESCE([User].[NICK],?) AS [NICK],CAST(([User].[PRICE]*[User].[QTY]) AS DECIMAL(10,2)) AS [AMOUNT],COUNT(DISTINCT [CITY]) AS [CITIES] FROM [User] WHERE ([User].[AGE]+?)>? GROUP BY LOWER([User].[NAME]) HAVING COUNT(*)>? ORDER BY (SUM([User].[PRICE])/COUNT([User].[ID])) DESC`, args: []interface{}{"none", 1, 18, 1}},
+		"sqlite":    {sql: `SELECT LOWER("User"."NAME") AS "NAME",COALESCE("User"."NICK",?) AS "NICK",CAST(("User"."PRICE"*"User"."QTY") AS DECIMAL(10,2)) AS "AMOUNT",COUNT(DISTINCT "CITY") AS "CITIES" FROM "User" WHERE ("User"."AGE"+?)>? GROUP BY LOWER("User"."NAME") HAVING COUNT(*)>? ORDER BY (SUM("User"."PRICE")/COUNT("User"."ID")) DESC`, args: []interface{}{"none", 1, 18, 1}},
+		"postgres":  {sql: `SELECT LOWER("User"."NAME") AS "NAME",COALESCE("User"."NICK",$1) AS "NICK",CAST(("User"."PRICE"*"User"."QTY") AS DECIMAL(10,2)) AS "AMOUNT",COUNT(DISTINCT "CITY") AS "CITIES" FROM "User" WHERE ("User"."AGE"+$2)>$3 GROUP BY LOWER("User"."NAME") HAVING COUNT(*)>$4 ORDER BY (SUM("User"."PRICE")/COUNT("User"."ID")) DESC`, args: []interface{}{"none", 1, 18, 1}},
+	})
+}
+
+func TestExprMinus(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		o := T("Order")
+		sc := newSelectContext(nil, d, &SelectInfo{columns: C(false).AddX(Minus(Max(Col(o, "AMOUNT")), Min(Col(o, "AMOUNT"))), "RANGE").AddX(Avg(Col(o, "AMOUNT")), "AVG").columns})
+		sc.From(o)
+		return sc
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT (MAX(`Order`.`AMOUNT`)-MIN(`Order`.`AMOUNT`)) AS `RANGE`,AVG(`Order`.`AMOUNT`) AS `AVG` FROM `Order`"},
+		"mssql":     {sql: `SELECT (MAX([Order].[AMOUNT])-MIN([Order].[AMOUNT])) AS [RANGE],AVG([Order].[AMOUNT]) AS [AVG] FROM [Order]`},
+		"mssql2005": {sql: `SELECT (MAX([Order].[AMOUNT])-MIN([Order].[AMOUNT])) AS [RANGE],AVG([Order].[AMOUNT]) AS [AVG] FROM [Order]`},
+		"sqlite":    {sql: `SELECT (MAX("Order"."AMOUNT")-MIN("Order"."AMOUNT")) AS "RANGE",AVG("Order"."AMOUNT") AS "AVG" FROM "Order"`},
+		"postgres":  {sql: `SELECT (MAX("Order"."AMOUNT")-MIN("Order"."AMOUNT")) AS "RANGE",AVG("Order"."AMOUNT") AS "AVG" FROM "Order"`},
+	})
+}
+
+func TestWindow(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		o := T("Order")
+		cols := o.C("ID").AddX(Over(RowNumber(), o.G("USER_ID"), o.S(SORT_DESC, "AMOUNT")), "RN").
+			AddX(Over(Sum(Col(o, "AMOUNT")), o.G("USER_ID"), nil), "TOTAL").
+			AddX(Over(Rank(), nil, nil), "R")
+		sc := newSelectContext(nil, d, &SelectInfo{columns: cols.columns})
+		sc.From(o)
+		return sc
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `Order`.`ID`,ROW_NUMBER() OVER(PARTITION BY `Order`.`USER_ID` ORDER BY `Order`.`AMOUNT` DESC) AS `RN`,SUM(`Order`.`AMOUNT`) OVER(PARTITION BY `Order`.`USER_ID`) AS `TOTAL`,RANK() OVER() AS `R` FROM `Order`"},
+		"mssql":     {sql: `SELECT [Order].[ID],ROW_NUMBER() OVER(PARTITION BY [Order].[USER_ID] ORDER BY [Order].[AMOUNT] DESC) AS [RN],SUM([Order].[AMOUNT]) OVER(PARTITION BY [Order].[USER_ID]) AS [TOTAL],RANK() OVER() AS [R] FROM [Order]`},
+		"mssql2005": {sql: `SELECT [Order].[ID],ROW_NUMBER() OVER(PARTITION BY [Order].[USER_ID] ORDER BY [Order].[AMOUNT] DESC) AS [RN],SUM([Order].[AMOUNT]) OVER(PARTITION BY [Order].[USER_ID]) AS [TOTAL],RANK() OVER() AS [R] FROM [Order]`},
+		"sqlite":    {sql: `SELECT "Order"."ID",ROW_NUMBER() OVER(PARTITION BY "Order"."USER_ID" ORDER BY "Order"."AMOUNT" DESC) AS "RN",SUM("Order"."AMOUNT") OVER(PARTITION BY "Order"."USER_ID") AS "TOTAL",RANK() OVER() AS "R" FROM "Order"`},
+		"postgres":  {sql: `SELECT "Order"."ID",ROW_NUMBER() OVER(PARTITION BY "Order"."USER_ID" ORDER BY "Order"."AMOUNT" DESC) AS "RN",SUM("Order"."AMOUNT") OVER(PARTITION BY "Order"."USER_ID") AS "TOTAL",RANK() OVER() AS "R" FROM "Order"`},
+	})
+}
+
+func TestWindowPage(t *testing.T) {
+	// window columns are referenced by alias in the paging wrapper of SQL Server 2005
+	testBuild(t, func(d Dialect) interface{} {
+		o := T("Order")
+		cols := o.C("ID").AddX(Over(DenseRank(), o.G("USER_ID"), o.S(SORT_DESC, "AMOUNT")), "DR")
+		sc := newSelectContext(nil, d, &SelectInfo{columns: cols.columns})
+		sc.From(o)
+		sc.OrderBy(o.S(SORT_ASC, "ID")).Limit(10, 10)
+		return sc
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `Order`.`ID`,DENSE_RANK() OVER(PARTITION BY `Order`.`USER_ID` ORDER BY `Order`.`AMOUNT` DESC) AS `DR` FROM `Order` ORDER BY `Order`.`ID` ASC LIMIT 10,10"},
+		"mssql":     {sql: `SELECT [Order].[ID],DENSE_RANK() OVER(PARTITION BY [Order].[USER_ID] ORDER BY [Order].[AMOUNT] DESC) AS [DR] FROM [Order] ORDER BY [Order].[ID] ASC OFFSET 10 ROWS FETCH NEXT 10 ROWS ONLY`},
+		"mssql2005": {sql: `SELECT [ID],[DR] FROM (SELECT [Order].[ID],DENSE_RANK() OVER(PARTITION BY [Order].[USER_ID] ORDER BY [Order].[AMOUNT] DESC) AS [DR],ROW_NUMBER() OVER(ORDER BY [Order].[ID] ASC) AS _N FROM [Order]) AS _T WHERE _N>10 AND _N<=20`},
+		"sqlite":    {sql: `SELECT "Order"."ID",DENSE_RANK() OVER(PARTITION BY "Order"."USER_ID" ORDER BY "Order"."AMOUNT" DESC) AS "DR" FROM "Order" ORDER BY "Order"."ID" ASC LIMIT 10 OFFSET 10`},
+		"postgres":  {sql: `SELECT "Order"."ID",DENSE_RANK() OVER(PARTITION BY "Order"."USER_ID" ORDER BY "Order"."AMOUNT" DESC) AS "DR" FROM "Order" ORDER BY "Order"."ID" ASC LIMIT 10 OFFSET 10`},
+	})
+}
+
+func TestWindowOffset(t *testing.T) {
+	// LAG and LEAD are not supported by SQL Server 2005, offset is written as literal
+	want := map[string]string{
+		"mysql":    "SELECT LAG(`Order`.`AMOUNT`,1,?) OVER(ORDER BY `Order`.`ID` ASC) AS `PREV`,LEAD(`Order`.`AMOUNT`,2) OVER(ORDER BY `Order`.`ID` ASC) AS `NEXT` FROM `Order`",
+		"mssql":    `SELECT LAG([Order].[AMOUNT],1,?) OVER(ORDER BY [Order].[ID] ASC) AS [PREV],LEAD([Order].[AMOUNT],2) OVER(ORDER BY [Order].[ID] ASC) AS [NEXT] FROM [Order]`,
+		"sqlite":   `SELECT LAG("Order"."AMOUNT",1,?) OVER(ORDER BY "Order"."ID" ASC) AS "PREV",LEAD("Order"."AMOUNT",2) OVER(ORDER BY "Order"."ID" ASC) AS "NEXT" FROM "Order"`,
+		"postgres": `SELECT LAG("Order"."AMOUNT",1,$1) OVER(ORDER BY "Order"."ID" ASC) AS "PREV",LEAD("Order"."AMOUNT",2) OVER(ORDER BY "Order"."ID" ASC) AS "NEXT" FROM "Order"`,
+	}
+	for p, sql := range want {
+		d, _ := getDialect(p)
+		o := T("Order")
+		cols := C(false).AddX(Over(Lag(Col(o, "AMOUNT"), 1, 0), nil, o.S(SORT_ASC, "ID")), "PREV").
+			AddX(Over(Lead(Col(o, "AMOUNT"), 2), nil, o.S(SORT_ASC, "ID")), "NEXT")
+		sc := newSelectContext(nil, d, &SelectInfo{columns: cols.columns})
+		sc.From(o)
+
+		got, args, err := Debug(sc)
+		if err != nil {
+			t.Errorf("%s: %v", p, err)
+		} else if got != sql || len(args) != 1 || args[0] != 0 {
+			t.Errorf("%s: got %s %v\nwant: %s", p, got, args, sql)
+		}
+	}
+}
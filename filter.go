@@ -1,7 +1,7 @@
 package gsd
 
 import (
-// "bytes"
+	"strings"
 )
 
 /********** filterType **********/
@@ -17,13 +17,84 @@ const (
 	FILTER_GTE
 	FILTER_IN
 	FILTER_LK
+	FILTER_BETWEEN // value must be a slice or array with 2 items
+	FILTER_NIN
+	FILTER_NLK
+	FILTER_START // LIKE 'value%'
+	FILTER_END   // LIKE '%value'
+	FILTER_NULL  // IS NULL, value is ignored
+	FILTER_NNULL // IS NOT NULL, value is ignored
 )
 
+// likeEscape is the escape character of LIKE filters
+const likeEscape = "!"
+
+// _LikeReplacer escapes wildcards in value of LIKE filters
+var _LikeReplacer = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_", "[", likeEscape+"[")
+
+func (this filterType) String() string {
+	switch this {
+	case FILTER_EQ:
+		return "EQ"
+	case FILTER_NE:
+		return "NE"
+	case FILTER_LT:
+		return "LT"
+	case FILTER_GT:
+		return "GT"
+	case FILTER_LTE:
+		return "LTE"
+	case FILTER_GTE:
+		return "GTE"
+	case FILTER_IN:
+		return "IN"
+	case FILTER_LK:
+		return "LK"
+	case FILTER_BETWEEN:
+		return "BETWEEN"
+	case FILTER_NIN:
+		return "NIN"
+	case FILTER_NLK:
+		return "NLK"
+	case FILTER_START:
+		return "START"
+	case FILTER_END:
+		return "END"
+	case FILTER_NULL:
+		return "NULL"
+	case FILTER_NNULL:
+		return "NNULL"
+	default:
+		return ""
+	}
+}
+
+// operator returns comparison operator of filter type, or empty string if it is not a comparison
+func (this filterType) operator() string {
+	switch this {
+	case FILTER_EQ:
+		return "="
+	case FILTER_NE:
+		return "<>"
+	case FILTER_LT:
+		return "<"
+	case FILTER_GT:
+		return ">"
+	case FILTER_LTE:
+		return "<="
+	case FILTER_GTE:
+		return ">="
+	default:
+		return ""
+	}
+}
+
 /********** oneColumnFilter **********/
 
 type oneColumnFilter struct {
 	table  Table
 	column string
+	expr   Expr // typed expression used instead of table and column
 	ft     filterType
 	value  interface{}
 }
@@ -44,6 +115,13 @@ type exprFilter struct {
 	expr string
 }
 
+/********** existsFilter **********/
+
+type existsFilter struct {
+	not   bool
+	query interface{}
+}
+
 /********** Filters **********/
 
 type Filters interface {
@@ -59,6 +137,9 @@ type BasicFilters interface {
 	AddF(t Table, col string, ft filterType, value interface{}) BasicFilters
 	AddJ(t1 Table, col1 string, ft filterType, t2 Table, col2 string) BasicFilters
 	AddE(expr string) BasicFilters
+	AddX(e Expr, ft filterType, value interface{}) BasicFilters
+	Exists(q interface{}) BasicFilters
+	NotExists(q interface{}) BasicFilters
 }
 
 /********** basicFilters **********/
@@ -88,7 +169,13 @@ func (this *basicFilters) Add(col string, value interface{}) BasicFilters {
 	return this.AddF(nil, col, FILTER_EQ, value)
 }
 
-// add filter with specific type
+// add filter with specific type, value can be a select clause for subquery, like:
+//
+//	F().AddT("ID", FILTER_IN, db.Select(t.C("USER_ID")).From(t).Where(f))
+//
+// value of FILTER_IN can also be a slice, each item of it is bound as a parameter, like:
+//
+//	F().AddT("ID", FILTER_IN, []int{1, 2, 3})
 func (this *basicFilters) AddT(col string, ft filterType, value interface{}) BasicFilters {
 	return this.AddF(nil, col, ft, value)
 }
@@ -127,6 +214,31 @@ func (this *basicFilters) AddE(expr string) BasicFilters {
 	return this
 }
 
+// add typed expression filter, value can also be an Expr, like:
+//
+//	F().AddX(gsd.Sum(gsd.Col(t, "AMOUNT")), FILTER_GT, 100)
+func (this *basicFilters) AddX(e Expr, ft filterType, value interface{}) BasicFilters {
+	f := &oneColumnFilter{
+		expr:  e,
+		ft:    ft,
+		value: value,
+	}
+	this.items = append(this.items, f)
+	return this
+}
+
+// add EXISTS filter, q must be a select clause, like: db.Select(...).From(t).Where(f)
+func (this *basicFilters) Exists(q interface{}) BasicFilters {
+	this.items = append(this.items, &existsFilter{query: q})
+	return this
+}
+
+// add NOT EXISTS filter, q must be a select clause
+func (this *basicFilters) NotExists(q interface{}) BasicFilters {
+	this.items = append(this.items, &existsFilter{not: true, query: q})
+	return this
+}
+
 /********** notFilters **********/
 
 type notFilters struct {
@@ -0,0 +1,105 @@
+package gsd
+
+import (
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u := T("User")
+		f := F().AddT("ID", FILTER_IN, []int{1, 2}).
+			AddT("AGE", FILTER_BETWEEN, []int{18, 60}).
+			AddT("NAME", FILTER_LK, "a_b").
+			AddT("EMAIL", FILTER_START, "x%").
+			AddT("DELETED", FILTER_NULL, nil).
+			AddT("STATE", FILTER_NE, 0)
+		s := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID").columns})
+		s.From(u).Where(f)
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `User`.`ID` FROM `User` WHERE `ID` IN(?,?) AND `AGE` BETWEEN ? AND ? AND `NAME` LIKE ? ESCAPE '!' AND `EMAIL` LIKE ? ESCAPE '!' AND `DELETED` IS NULL AND `STATE`<>?", args: []interface{}{1, 2, 18, 60, "%a!_b%", "x!%%", 0}},
+		"mssql":     {sql: "SELECT [User].[ID] FROM [User] WHERE [ID] IN(?,?) AND [AGE] BETWEEN ? AND ? AND [NAME] LIKE ? ESCAPE '!' AND [EMAIL] LIKE ? ESCAPE '!' AND [DELETED] IS NULL AND [STATE]<>?", args: []interface{}{1, 2, 18, 60, "%a!_b%", "x!%%", 0}},
+		"mssql2005": {sql: "SELECT [User].[ID] FROM [User] WHERE [ID] IN(?,?) AND [AGE] BETWEEN ? AND ? AND [NAME] LIKE ? ESCAPE '!' AND [EMAIL] LIKE ? ESCAPE '!' AND [DELETED] IS NULL AND [STATE]<>?", args: []interface{}{1, 2, 18, 60, "%a!_b%", "x!%%", 0}},
+		"sqlite":    {sql: `SELECT "User"."ID" FROM "User" WHERE "ID" IN(?,?) AND "AGE" BETWEEN ? AND ? AND "NAME" LIKE ? ESCAPE '!' AND "EMAIL" LIKE ? ESCAPE '!' AND "DELETED" IS NULL AND "STATE"<>?`, args: []interface{}{1, 2, 18, 60, "%a!_b%", "x!%%", 0}},
+		"postgres":  {sql: `SELECT "User"."ID" FROM "User" WHERE "ID" IN($1,$2) AND "AGE" BETWEEN $3 AND $4 AND "NAME" ILIKE $5 ESCAPE '!' AND "EMAIL" ILIKE $6 ESCAPE '!' AND "DELETED" IS NULL AND "STATE"<>$7`, args: []interface{}{1, 2, 18, 60, "%a!_b%", "x!%%", 0}},
+	})
+}
+
+func TestSubqueryFilters(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u, o := T("User"), T("Order")
+		sub := newSelectContext(nil, d, &SelectInfo{columns: o.C("USER_ID").columns})
+		sub.From(o).Where(F().AddT("AMOUNT", FILTER_GT, 100))
+		s := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID").columns})
+		s.From(u).Where(F().Add("STATE", 1).AddT("ID", FILTER_NIN, sub).AddT("ID", FILTER_IN, []int{}))
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `User`.`ID` FROM `User` WHERE `STATE`=? AND `ID` NOT IN(SELECT `Order`.`USER_ID` FROM `Order` WHERE `AMOUNT`>?) AND 1=0", args: []interface{}{1, 100}},
+		"mssql":     {sql: "SELECT [User].[ID] FROM [User] WHERE [STATE]=? AND [ID] NOT IN(SELECT [Order].[USER_ID] FROM [Order] WHERE [AMOUNT]>?) AND 1=0", args: []interface{}{1, 100}},
+		"mssql2005": {sql: "SELECT [User].[ID] FROM [User] WHERE [STATE]=? AND [ID] NOT IN(SELECT [Order].[USER_ID] FROM [Order] WHERE [AMOUNT]>?) AND 1=0", args: []interface{}{1, 100}},
+		"sqlite":    {sql: `SELECT "User"."ID" FROM "User" WHERE "STATE"=? AND "ID" NOT IN(SELECT "Order"."USER_ID" FROM "Order" WHERE "AMOUNT">?) AND 1=0`, args: []interface{}{1, 100}},
+		"postgres":  {sql: `SELECT "User"."ID" FROM "User" WHERE "STATE"=$1 AND "ID" NOT IN(SELECT "Order"."USER_ID" FROM "Order" WHERE "AMOUNT">$2) AND 1=0`, args: []interface{}{1, 100}},
+	})
+}
+
+func TestCompositeFilters(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u := T("User")
+		s := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID").columns})
+		s.From(u).Where(F().Add("A", 1).Or(F().Add("B", 2).Not()))
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `User`.`ID` FROM `User` WHERE (`A`=?) OR (NOT(`B`=?))", args: []interface{}{1, 2}},
+		"mssql":     {sql: "SELECT [User].[ID] FROM [User] WHERE ([A]=?) OR (NOT([B]=?))", args: []interface{}{1, 2}},
+		"mssql2005": {sql: "SELECT [User].[ID] FROM [User] WHERE ([A]=?) OR (NOT([B]=?))", args: []interface{}{1, 2}},
+		"sqlite":    {sql: `SELECT "User"."ID" FROM "User" WHERE ("A"=?) OR (NOT("B"=?))`, args: []interface{}{1, 2}},
+		"postgres":  {sql: `SELECT "User"."ID" FROM "User" WHERE ("A"=$1) OR (NOT("B"=$2))`, args: []interface{}{1, 2}},
+	})
+}
+
+func TestInStringFilter(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u := T("User")
+		s := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID").columns})
+		s.From(u).Where(F().AddT("ID", FILTER_IN, "1,2").AddT("STATE", FILTER_NIN, "3"))
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `User`.`ID` FROM `User` WHERE `ID` IN(1,2) AND `STATE` NOT IN(3)"},
+		"mssql":     {sql: "SELECT [User].[ID] FROM [User] WHERE [ID] IN(1,2) AND [STATE] NOT IN(3)"},
+		"mssql2005": {sql: "SELECT [User].[ID] FROM [User] WHERE [ID] IN(1,2) AND [STATE] NOT IN(3)"},
+		"sqlite":    {sql: `SELECT "User"."ID" FROM "User" WHERE "ID" IN(1,2) AND "STATE" NOT IN(3)`},
+		"postgres":  {sql: `SELECT "User"."ID" FROM "User" WHERE "ID" IN(1,2) AND "STATE" NOT IN(3)`},
+	})
+}
+
+func TestInFilterGroups(t *testing.T) {
+	d := newPostgresBuilder()
+	d.MaxInItems = 2
+
+	u := T("User")
+	s := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID").columns})
+	s.From(u).Where(F().AddT("ID", FILTER_IN, []int{1, 2, 3}).AddT("STATE", FILTER_NIN, []int{4, 5, 6, 7, 8}))
+	sql, args, err := Debug(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `SELECT "User"."ID" FROM "User" WHERE ("ID" IN($1,$2) OR "ID" IN($3)) AND ("STATE" NOT IN($4,$5) AND "STATE" NOT IN($6,$7) AND "STATE" NOT IN($8))`
+	if sql != want {
+		t.Errorf("sql mismatch\n got: %s\nwant: %s", sql, want)
+	}
+	if len(args) != 8 {
+		t.Errorf("expect 8 args, got %v", args)
+	}
+}
+
+func TestInFilterLimit(t *testing.T) {
+	// groups don't reduce parameters of the statement, so it still fails
+	d := newMssqlBuilder()
+	u := T("User")
+	s := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID").columns})
+	s.From(u).Where(F().AddT("ID", FILTER_IN, make([]int, 2101)))
+	if _, _, err := Debug(s); err == nil {
+		t.Error("expect error of too many parameters")
+	}
+}
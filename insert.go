@@ -1,22 +1,35 @@
 package gsd
 
-/********** insertInfo **********/
+import (
+	"context"
+	"fmt"
+	"sort"
+)
 
-type insertInfo struct {
-	table  string
-	values map[string]interface{}
-	// todo: add subquery supporting, like: insert into X(a,b,c) select a1, b1, c1 from Y
+// DEFAULT_BATCH_SIZE is the max rows of a batch insert statement if BatchSize setting is not configured
+const DEFAULT_BATCH_SIZE = 1000
+
+/********** InsertInfo **********/
+
+type InsertInfo struct {
+	table     string
+	values    map[string]interface{}
+	columns   []string        // columns for inserting from query or batch
+	query     interface{}     // select clause, like: insert into X(a,b,c) select a1, b1, c1 from Y
+	rows      [][]interface{} // values of batch, in the order of columns
+	batch     int             // max rows of a batch statement
+	returning []string
 }
 
 /********** insertContext **********/
 
 type insertContext struct {
 	exe  executor
-	b    builder
-	info *insertInfo
+	b    Dialect
+	info *InsertInfo
 }
 
-func newInsertContext(exe executor, b builder, info *insertInfo) *insertContext {
+func newInsertContext(exe executor, b Dialect, info *InsertInfo) *insertContext {
 	return &insertContext{
 		exe:  exe,
 		b:    b,
@@ -29,13 +42,168 @@ func (this *insertContext) Values(values InsertValues) InsertResultClause {
 	return this
 }
 
+// ValuesBatch inserts multiple rows with multi-row VALUES statements, all items must have the same columns.
+// Rows are split into statements according to BatchSize setting and MaxParams of the dialect,
+// use Transact if they must be inserted atomically.
+func (this *insertContext) ValuesBatch(values []InsertValues) ResultClause {
+	return &batchInsertContext{insertContext: this, values: values}
+}
+
+func (this *insertContext) Columns(cols ...string) InsertColumnsClause {
+	this.info.columns = cols
+	return this
+}
+
+// From sets the query whose result is inserted, q must be a select or compound clause, like: db.Select(...).From(t).Where(f)
+func (this *insertContext) From(q interface{}) InsertResultClause {
+	this.info.query = q
+	return this
+}
+
 func (this *insertContext) Result() (InsertResult, error) {
-	ctx := newBuildContext()
-	err := this.b.BuildInsert(ctx, this.info)
+	return this.ResultContext(context.Background())
+}
+
+func (this *insertContext) ResultContext(c context.Context) (InsertResult, error) {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildInsert(ctx, this.info))
 	if err != nil {
 		return nil, err
 	}
-	return this.exe.Exec(ctx.GetSql(), ctx.GetParams()...)
+	return this.exe.ExecContext(c, ctx.GetSql(), ctx.GetParams()...)
+}
+
+// Returning returns columns of inserted rows instead of the result, like generated keys and default values
+func (this *insertContext) Returning(cols ...string) RowClause {
+	this.info.returning = cols
+	return this
+}
+
+func (this *insertContext) Row() Row {
+	return this.RowContext(context.Background())
+}
+
+func (this *insertContext) Rows() Rows {
+	return this.RowsContext(context.Background())
+}
+
+func (this *insertContext) RowContext(c context.Context) Row {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildInsert(ctx, this.info))
+	return newBuiltRow(this.exe, c, ctx, err)
+}
+
+func (this *insertContext) RowsContext(c context.Context) Rows {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildInsert(ctx, this.info))
+	return newBuiltRows(this.exe, c, ctx, err)
+}
+
+/********** batchInsertContext **********/
+
+type batchInsertContext struct {
+	*insertContext
+	values []InsertValues
+}
+
+func (this *batchInsertContext) Result() (Result, error) {
+	return this.ResultContext(context.Background())
+}
+
+// ResultContext executes all batch statements, RowsAffected of the result is the total count,
+// it returns an error if the driver can't report affected rows of any statement.
+func (this *batchInsertContext) ResultContext(c context.Context) (Result, error) {
+	if err := this.prepare(); err != nil {
+		return nil, err
+	}
+
+	chunks, err := this.chunks()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &batchResult{}
+	for _, chunk := range chunks {
+		info := &InsertInfo{table: this.info.table, columns: this.info.columns, rows: chunk}
+		ctx := newBuildContext(this.b)
+		if err := ctx.check(this.b.BuildInsert(ctx, info)); err != nil {
+			return r, err
+		}
+
+		res, err := this.exe.ExecContext(c, ctx.GetSql(), ctx.GetParams()...)
+		if err != nil {
+			return r, err
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			r.rows += n
+		} else if r.err == nil {
+			r.err = err
+		}
+	}
+	return r, nil
+}
+
+// prepare converts values to rows in the order of columns of the first item
+func (this *batchInsertContext) prepare() error {
+	if len(this.values) == 0 {
+		return fmt.Errorf("no values to insert")
+	}
+
+	this.info.columns = this.values[0].Keys()
+
+	this.info.rows = make([][]interface{}, len(this.values))
+	for i, values := range this.values {
+		if len(values) != len(this.info.columns) {
+			return fmt.Errorf("columns of row %d don't match the first row", i)
+		}
+
+		row := make([]interface{}, len(this.info.columns))
+		for j, col := range this.info.columns {
+			v, ok := values[col]
+			if !ok {
+				return fmt.Errorf("column [%s] is missing in row %d", col, i)
+			}
+			row[j] = v
+		}
+		this.info.rows[i] = row
+	}
+	return nil
+}
+
+// chunks splits rows, so every statement has no more than batch rows, MaxInsertRows rows and MaxParams parameters.
+// Statement size limits like max_allowed_packet of MySQL are not considered, use BatchSize setting to keep statements small.
+func (this *batchInsertContext) chunks() (chunks [][][]interface{}, err error) {
+	size := this.info.batch
+	if size <= 0 {
+		size = DEFAULT_BATCH_SIZE
+	}
+	if max := this.b.MaxParams(); max > 0 && size*len(this.info.columns) > max {
+		if len(this.info.columns) > max {
+			return nil, fmt.Errorf("too many columns to insert: %d, the database allows %d parameters in a statement", len(this.info.columns), max)
+		}
+		size = max / len(this.info.columns)
+	}
+	if b := builderOf(this.b); b != nil && b.MaxInsertRows > 0 && size > b.MaxInsertRows {
+		size = b.MaxInsertRows
+	}
+
+	rows := this.info.rows
+	for len(rows) > size {
+		chunks = append(chunks, rows[:size])
+		rows = rows[size:]
+	}
+	return append(chunks, rows), nil
+}
+
+/********** batchResult **********/
+
+type batchResult struct {
+	rows int64
+	err  error // error of RowsAffected of any statement
+}
+
+func (this *batchResult) RowsAffected() (int64, error) {
+	return this.rows, this.err
 }
 
 /********** InsertValues **********/
@@ -56,3 +224,13 @@ func (this InsertValues) Remove(name string) InsertValues {
 	delete(this, name)
 	return this
 }
+
+// Keys returns sorted column names, so generated SQL is the same for the same columns
+func (this InsertValues) Keys() []string {
+	keys := make([]string, 0, len(this))
+	for k := range this {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
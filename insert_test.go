@@ -0,0 +1,137 @@
+package gsd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInsert(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		return newInsertContext(nil, d, &InsertInfo{table: "User"}).Values(InsertValues{"NAME": "a", "AGE": 20})
+	}, map[string]golden{
+		"mysql":     {sql: "INSERT INTO `User`(`AGE`,`NAME`) VALUES(?,?)", args: []interface{}{20, "a"}},
+		"mssql":     {sql: "INSERT INTO [User]([AGE],[NAME]) VALUES(?,?)", args: []interface{}{20, "a"}},
+		"mssql2005": {sql: "INSERT INTO [User]([AGE],[NAME]) VALUES(?,?)", args: []interface{}{20, "a"}},
+		"sqlite":    {sql: `INSERT INTO "User"("AGE","NAME") VALUES(?,?)`, args: []interface{}{20, "a"}},
+		"postgres":  {sql: `INSERT INTO "User"("AGE","NAME") VALUES($1,$2)`, args: []interface{}{20, "a"}},
+	})
+}
+
+func TestInsertReturning(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		return newInsertContext(nil, d, &InsertInfo{table: "User"}).Values(InsertValues{"NAME": "a"}).Returning("ID")
+	}, map[string]golden{
+		"mysql":     {err: true},
+		"mssql":     {sql: "INSERT INTO [User]([NAME]) OUTPUT INSERTED.[ID] VALUES(?)", args: []interface{}{"a"}},
+		"mssql2005": {sql: "INSERT INTO [User]([NAME]) OUTPUT INSERTED.[ID] VALUES(?)", args: []interface{}{"a"}},
+		"sqlite":    {sql: `INSERT INTO "User"("NAME") VALUES(?) RETURNING "User"."ID"`, args: []interface{}{"a"}},
+		"postgres":  {sql: `INSERT INTO "User"("NAME") VALUES($1) RETURNING "User"."ID"`, args: []interface{}{"a"}},
+	})
+}
+
+func TestInsertBatch(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		values := []InsertValues{{"NAME": "a", "AGE": 20}, {"NAME": "b", "AGE": 30}}
+		return newInsertContext(nil, d, &InsertInfo{table: "User"}).ValuesBatch(values)
+	}, map[string]golden{
+		"mysql":     {sql: "INSERT INTO `User`(`AGE`,`NAME`) VALUES(?,?),(?,?)", args: []interface{}{20, "a", 30, "b"}},
+		"mssql":     {sql: "INSERT INTO [User]([AGE],[NAME]) VALUES(?,?),(?,?)", args: []interface{}{20, "a", 30, "b"}},
+		"mssql2005": {sql: "INSERT INTO [User]([AGE],[NAME]) SELECT ?,? UNION ALL SELECT ?,?", args: []interface{}{20, "a", 30, "b"}},
+		"sqlite":    {sql: `INSERT INTO "User"("AGE","NAME") VALUES(?,?),(?,?)`, args: []interface{}{20, "a", 30, "b"}},
+		"postgres":  {sql: `INSERT INTO "User"("AGE","NAME") VALUES($1,$2),($3,$4)`, args: []interface{}{20, "a", 30, "b"}},
+	})
+}
+
+func TestInsertBatchChunks(t *testing.T) {
+	d, _ := getDialect("sqlite")
+	values := make([]InsertValues, 1000)
+	for i := range values {
+		values[i] = InsertValues{"A": i, "B": i}
+	}
+
+	// SQLite allows 999 parameters, so a statement has 499 rows at most
+	ctx := newInsertContext(nil, d, &InsertInfo{table: "T", batch: DEFAULT_BATCH_SIZE}).ValuesBatch(values).(*batchInsertContext)
+	if err := ctx.prepare(); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := ctx.chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 || len(chunks[0]) != 499 || len(chunks[2]) != 2 {
+		t.Errorf("unexpected chunks: %d", len(chunks))
+	}
+}
+
+func TestInsertBatchMaxRows(t *testing.T) {
+	values := make([]InsertValues, 1500)
+	for i := range values {
+		values[i] = InsertValues{"A": i}
+	}
+
+	// SQL Server accepts 1000 rows in a statement even if BatchSize is larger
+	for _, p := range []string{"mssql", "mssql2005"} {
+		d, _ := getDialect(p)
+		ctx := newInsertContext(nil, d, &InsertInfo{table: "T", batch: 2000}).ValuesBatch(values).(*batchInsertContext)
+		if err := ctx.prepare(); err != nil {
+			t.Fatal(err)
+		}
+		chunks, err := ctx.chunks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunks) != 2 || len(chunks[0]) != 1000 || len(chunks[1]) != 500 {
+			t.Errorf("%s: unexpected chunks: %d", p, len(chunks))
+		}
+	}
+}
+
+func TestInsertBatchTooWide(t *testing.T) {
+	d, _ := getDialect("sqlite")
+	v := InsertValues{}
+	for i := 0; i < 1000; i++ {
+		v[fmt.Sprint("C", i)] = i
+	}
+
+	ctx := newInsertContext(nil, d, &InsertInfo{table: "T"}).ValuesBatch([]InsertValues{v}).(*batchInsertContext)
+	if err := ctx.prepare(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctx.chunks(); err == nil {
+		t.Error("expect error of too many columns")
+	}
+}
+
+func TestInsertSelect(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		c := T("Category")
+		q := newSelectContext(nil, d, &SelectInfo{columns: c.C("ID", "NAME").columns})
+		q.From(c).Where(F().AddT("COUNT", FILTER_GT, 0))
+		return newInsertContext(nil, d, &InsertInfo{table: "Backup"}).Columns("ID", "NAME").From(q)
+	}, map[string]golden{
+		"mysql":     {sql: "INSERT INTO `Backup`(`ID`,`NAME`) SELECT `Category`.`ID`,`Category`.`NAME` FROM `Category` WHERE `COUNT`>?", args: []interface{}{0}},
+		"mssql":     {sql: "INSERT INTO [Backup]([ID],[NAME]) SELECT [Category].[ID],[Category].[NAME] FROM [Category] WHERE [COUNT]>?", args: []interface{}{0}},
+		"mssql2005": {sql: "INSERT INTO [Backup]([ID],[NAME]) SELECT [Category].[ID],[Category].[NAME] FROM [Category] WHERE [COUNT]>?", args: []interface{}{0}},
+		"sqlite":    {sql: `INSERT INTO "Backup"("ID","NAME") SELECT "Category"."ID","Category"."NAME" FROM "Category" WHERE "COUNT">?`, args: []interface{}{0}},
+		"postgres":  {sql: `INSERT INTO "Backup"("ID","NAME") SELECT "Category"."ID","Category"."NAME" FROM "Category" WHERE "COUNT">$1`, args: []interface{}{0}},
+	})
+}
+
+func TestInsertCompound(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		a, b := T("A"), T("B")
+		q1 := newSelectContext(nil, d, &SelectInfo{columns: a.C("ID").columns})
+		q1.From(a).Where(F().Add("X", 1))
+		q2 := newSelectContext(nil, d, &SelectInfo{columns: b.C("ID").columns})
+		q2.From(b)
+		cc := newCompoundContext(nil, d, &CompoundInfo{queries: []interface{}{q1}})
+		cc.UnionAll(q2)
+		return newInsertContext(nil, d, &InsertInfo{table: "C"}).Columns().From(cc)
+	}, map[string]golden{
+		"mysql":     {sql: "INSERT INTO `C` SELECT * FROM ((SELECT `A`.`ID` FROM `A` WHERE `X`=?) UNION ALL (SELECT `B`.`ID` FROM `B`)) AS _C", args: []interface{}{1}},
+		"mssql":     {sql: "INSERT INTO [C] SELECT * FROM ((SELECT [A].[ID] FROM [A] WHERE [X]=?) UNION ALL (SELECT [B].[ID] FROM [B])) AS _C", args: []interface{}{1}},
+		"mssql2005": {sql: "INSERT INTO [C] SELECT * FROM ((SELECT [A].[ID] FROM [A] WHERE [X]=?) UNION ALL (SELECT [B].[ID] FROM [B])) AS _C", args: []interface{}{1}},
+		"sqlite":    {sql: `INSERT INTO "C" SELECT * FROM (SELECT "A"."ID" FROM "A" WHERE "X"=? UNION ALL SELECT "B"."ID" FROM "B") AS _C`, args: []interface{}{1}},
+		"postgres":  {sql: `INSERT INTO "C" SELECT * FROM ((SELECT "A"."ID" FROM "A" WHERE "X"=$1) UNION ALL (SELECT "B"."ID" FROM "B")) AS _C`, args: []interface{}{1}},
+	})
+}
@@ -0,0 +1,123 @@
+package gsd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// supported formats of config file
+const (
+	FORMAT_XML  = "xml"
+	FORMAT_JSON = "json"
+	FORMAT_YAML = "yaml"
+	FORMAT_TOML = "toml"
+)
+
+// LoadConfig reads configurations of databases from r, format can be xml, json, yaml or toml.
+//
+// Except xml, all formats share the same structure, for example in yaml:
+//
+//	databases:
+//	  - name: Test
+//	    provider: mysql
+//	    settings:
+//	      ConnString: user:password@tcp(localhost:3306)/Test?parseTime=true
+//	      MaxOpenConns: 100
+func LoadConfig(r io.Reader, format string) (configs map[string]*Config, err error) {
+	switch strings.ToLower(format) {
+	case FORMAT_XML:
+		configs, err = loadXmlConfig(r)
+	case FORMAT_JSON:
+		configs, err = loadJsonConfig(r)
+	case FORMAT_YAML, "yml":
+		configs, err = loadYamlConfig(r)
+	case FORMAT_TOML:
+		configs, err = loadTomlConfig(r)
+	default:
+		err = fmt.Errorf("not supported config format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cfg := range configs {
+		if cfg.Driver == "" {
+			cfg.Driver = cfg.Provider
+		}
+	}
+	return
+}
+
+// configFormat returns format of config file by its extension, the default is xml
+func configFormat(path string) string {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json", ".yaml", ".yml", ".toml":
+		return ext[1:]
+	default:
+		return FORMAT_XML
+	}
+}
+
+/********** configFile **********/
+
+// configFile is the structure of json/yaml/toml config files
+type configFile struct {
+	Databases []struct {
+		Name     string                 `json:"name" yaml:"name" toml:"name"`
+		Provider string                 `json:"provider" yaml:"provider" toml:"provider"`
+		Driver   string                 `json:"driver" yaml:"driver" toml:"driver"`
+		Settings map[string]interface{} `json:"settings" yaml:"settings" toml:"settings"`
+		Replicas []string               `json:"replicas" yaml:"replicas" toml:"replicas"`
+	} `json:"databases" yaml:"databases" toml:"databases"`
+}
+
+func (this *configFile) Configs() map[string]*Config {
+	configs := make(map[string]*Config)
+	for _, db := range this.Databases {
+		cfg := &Config{
+			Name:     db.Name,
+			Provider: db.Provider,
+			Driver:   db.Driver,
+			Settings: SettingMap{},
+			Replicas: db.Replicas,
+		}
+		// setting values may be numbers or booleans in these formats
+		for k, v := range db.Settings {
+			cfg.Settings[k] = fmt.Sprint(v)
+		}
+		configs[cfg.Name] = cfg
+	}
+	return configs
+}
+
+func loadJsonConfig(r io.Reader) (map[string]*Config, error) {
+	file := &configFile{}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(file); err != nil {
+		return nil, err
+	}
+	return file.Configs(), nil
+}
+
+func loadYamlConfig(r io.Reader) (map[string]*Config, error) {
+	file := &configFile{}
+	if err := yaml.NewDecoder(r).Decode(file); err != nil {
+		return nil, err
+	}
+	return file.Configs(), nil
+}
+
+func loadTomlConfig(r io.Reader) (map[string]*Config, error) {
+	file := &configFile{}
+	if _, err := toml.NewDecoder(r).Decode(file); err != nil {
+		return nil, err
+	}
+	return file.Configs(), nil
+}
@@ -0,0 +1,82 @@
+package gsd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	want := &Config{
+		Name:     "Test",
+		Provider: "mysql",
+		Driver:   "mysql",
+		Settings: SettingMap{"ConnString": "user:password@tcp(localhost:3306)/Test", "MaxOpenConns": "100", "PingOnOpen": "true"},
+		Replicas: []string{"user:password@tcp(replica:3306)/Test"},
+	}
+
+	files := map[string]string{
+		FORMAT_XML: `<databases>
+	<database name="Test" provider="mysql">
+		<setting name="ConnString" value="user:password@tcp(localhost:3306)/Test"/>
+		<setting name="MaxOpenConns" value="100"/>
+		<setting name="PingOnOpen" value="true"/>
+		<replica value="user:password@tcp(replica:3306)/Test"/>
+	</database>
+</databases>`,
+		FORMAT_JSON: `{"databases": [{
+	"name": "Test", "provider": "mysql",
+	"settings": {"ConnString": "user:password@tcp(localhost:3306)/Test", "MaxOpenConns": 100, "PingOnOpen": true},
+	"replicas": ["user:password@tcp(replica:3306)/Test"]
+}]}`,
+		FORMAT_YAML: `databases:
+  - name: Test
+    provider: mysql
+    settings:
+      ConnString: user:password@tcp(localhost:3306)/Test
+      MaxOpenConns: 100
+      PingOnOpen: true
+    replicas:
+      - user:password@tcp(replica:3306)/Test
+`,
+		FORMAT_TOML: `[[databases]]
+name = "Test"
+provider = "mysql"
+replicas = ["user:password@tcp(replica:3306)/Test"]
+
+[databases.settings]
+ConnString = "user:password@tcp(localhost:3306)/Test"
+MaxOpenConns = 100
+PingOnOpen = true
+`,
+	}
+
+	for format, content := range files {
+		configs, err := LoadConfig(strings.NewReader(content), format)
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		if got := configs["Test"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", format, got, want)
+		}
+	}
+}
+
+func TestLoadConfigFormat(t *testing.T) {
+	if _, err := LoadConfig(strings.NewReader(""), "ini"); err == nil {
+		t.Error("expect error of unknown format")
+	}
+
+	formats := map[string]string{
+		"database.sql.conf": FORMAT_XML,
+		"db.json":           FORMAT_JSON,
+		"db.YML":            "yml",
+		"db.toml":           FORMAT_TOML,
+	}
+	for path, want := range formats {
+		if got := configFormat(path); got != want {
+			t.Errorf("%s: got %s, want %s", path, got, want)
+		}
+	}
+}
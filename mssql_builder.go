@@ -9,306 +9,193 @@ import (
 
 // SQL Server 2012+ builder
 type mssqlBuilder struct {
+	*Builder
 }
 
-// BuildInsert build query string and parameters for insert action
-func (this *mssqlBuilder) BuildInsert(ctx *buildContext, info *insertInfo) error {
-	ctx.AppendSqlF("INSERT INTO [%s](", info.table)
-
-	first := true
-	for k, v := range info.values {
-		if first {
-			first = false
-		} else {
-			ctx.AppendSql(",")
-		}
-		ctx.AppendSqlF("[%s]", k)
-		ctx.AddParam(v)
-	}
-
-	ctx.AppendSql(") VALUES(?", strings.Repeat(",?", len(info.values)-1), ")")
-
-	return nil
+func newMssqlBuilder() *mssqlBuilder {
+	b := &mssqlBuilder{}
+	b.Builder = NewBuilder(b)
+	b.NoRecursive = true
+	b.Returning = RETURNING_OUTPUT
+	b.MaxInsertRows = 1000 // row constructor of VALUES accepts 1000 rows at most
+	return b
 }
 
-// BuildUpdate build query string and parameters for update action
-func (this *mssqlBuilder) BuildUpdate(ctx *buildContext, info *updateInfo) error {
-	ctx.AppendSqlF("UPDATE [%s] SET", info.table)
+// BuildUpsert build query string and parameters for upsert action with MERGE statement, every key must be in values
+func (this *mssqlBuilder) BuildUpsert(ctx *BuildContext, info *UpsertInfo) error {
+	if err := this.checkUpsert(info); err != nil {
+		return err
+	}
 
-	first := true
-	for k, v := range info.values {
-		if first {
-			first = false
-		} else {
+	// HOLDLOCK keeps the matched range locked, so concurrent upserts can't insert the same row
+	cols := make([]string, 0, len(info.values))
+	ctx.AppendSql("MERGE INTO ", this.Quote(info.table), " WITH (HOLDLOCK) AS _T USING (SELECT ")
+	for _, k := range InsertValues(info.values).Keys() {
+		if len(cols) > 0 {
 			ctx.AppendSql(",")
 		}
-
-		switch v.ut {
-		case UPDATE_INC:
-			ctx.AppendSqlF(" [%s]=[%s]+?", k, k)
-			ctx.AddParam(v.val)
-		case UPDATE_XP:
-			ctx.AppendSqlF(" [%s]=%s", k, v.val)
-		default:
-			ctx.AppendSqlF(" [%s]=?", k)
-			ctx.AddParam(v.val)
-		}
-	}
-
-	if info.where != nil {
-		ctx.AppendSql(" WHERE ")
-		this.BuildFilters(ctx, info.where)
+		ctx.AppendParam(info.values[k])
+		ctx.AppendSql(" AS ", this.Quote(k))
+		cols = append(cols, this.Quote(k))
 	}
 
-	return nil
-}
-
-// BuildDelete build query string and parameters for delete action
-func (this *mssqlBuilder) BuildDelete(ctx *buildContext, info *deleteInfo) error {
-	ctx.AppendSqlF("DELETE FROM [%s]", info.table)
-
-	if info.where != nil {
-		ctx.AppendSql(" WHERE ")
-		this.BuildFilters(ctx, info.where)
+	ctx.AppendSql(") AS _S ON ")
+	for i, k := range info.keys {
+		if i > 0 {
+			ctx.AppendSql(" AND ")
+		}
+		ctx.AppendSql("_T.", this.Quote(k), "=_S.", this.Quote(k))
 	}
 
-	return nil
-}
-
-// BuildSelect build query string and parameters for select action
-func (this *mssqlBuilder) BuildSelect(ctx *buildContext, info *selectInfo) error {
-	ctx.AppendSql("SELECT ")
-
-	if info.distinct {
-		ctx.AppendSql("DISTINCT ")
-	}
+	ctx.AppendSql(" WHEN MATCHED THEN UPDATE SET")
+	this.buildSetValues(ctx, info.updates, "_T.", false)
 
-	// SELECT
-	for i, c := range info.columns {
+	ctx.AppendSql(" WHEN NOT MATCHED THEN INSERT(", strings.Join(cols, ","), ") VALUES(")
+	for i, col := range cols {
 		if i > 0 {
 			ctx.AppendSql(",")
 		}
-
-		switch v := c.(type) {
-		case *normalColumn:
-			if v.table != nil {
-				ctx.AppendSqlF("[%s].", v.table.Prefix())
-			}
-			ctx.AppendSqlF("[%s]", v.column)
-			if v.alias != "" {
-				ctx.AppendSql(" AS ", v.alias)
-			}
-		case *exprColumn:
-			ctx.AppendSql(v.expr)
-			if v.alias != "" {
-				ctx.AppendSql(" AS ", v.alias)
-			}
-		}
+		ctx.AppendSql("_S.", col)
 	}
+	// MERGE statement must be terminated by a semicolon
+	ctx.AppendSql(");")
+	return nil
+}
 
-	// FROM
-	ctx.AppendSqlF(" FROM [%s]", info.table.Name())
-	if info.table.Alias() != "" {
-		ctx.AppendSql(" AS ", info.table.Alias())
+// checkUpsert validates keys and updates of upsert, rows are matched by values of keys, so every key must be in values
+func (this *mssqlBuilder) checkUpsert(info *UpsertInfo) error {
+	if len(info.keys) == 0 {
+		return fmt.Errorf("conflict keys of upsert are not set")
 	}
-
-	// JOIN
-	for _, j := range info.joins {
-		ctx.AppendSqlF(" %s [%s]", j.jt, j.t.Name())
-		if j.t.Alias() != "" {
-			ctx.AppendSql(" AS ", j.t.Alias())
+	if len(info.updates) == 0 {
+		return fmt.Errorf("update values of upsert are not set")
+	}
+	for _, k := range info.keys {
+		if _, ok := info.values[k]; !ok {
+			return fmt.Errorf("conflict key [%s] of upsert is missing in values", k)
 		}
-		ctx.AppendSql(" ON ")
-		this.BuildFilters(ctx, j.on)
 	}
+	return nil
+}
 
-	if info.where != nil {
-		ctx.AppendSql(" WHERE ")
-		this.BuildFilters(ctx, info.where)
+// BuildUpdate build query string and parameters for update action, like: UPDATE a SET ... FROM a JOIN b ON ...
+func (this *mssqlBuilder) BuildUpdate(ctx *BuildContext, info *UpdateInfo) error {
+	if len(info.joins) == 0 {
+		return this.Builder.BuildUpdate(ctx, info)
 	}
 
-	// GROUP BY
-	if len(info.groups) > 0 {
-		ctx.AppendSql(" GROUP BY ")
-		for i, g := range info.groups {
-			if i > 0 {
-				ctx.AppendSql(",")
-			}
-			for j, col := range g.columns {
-				if j > 0 {
-					ctx.AppendSql(",")
-				}
-				if g.table != nil {
-					ctx.AppendSqlF("[%s].", g.table.Prefix())
-				}
-				ctx.AppendSqlF("[%s]", col)
-			}
-		}
-
-		if info.having != nil {
-			ctx.AppendSql(" HAVING ")
-			this.BuildFilters(ctx, info.having)
-		}
+	table := this.Quote(info.table)
+	ctx.AppendSql("UPDATE ", table, " SET")
+	this.buildSetValues(ctx, info.values, table+".", false)
+	this.buildOutput(ctx, info.returning, "INSERTED")
+	ctx.AppendSql(" FROM ", table)
+	if err := this.buildJoins(ctx, info.joins); err != nil {
+		return err
 	}
+	return this.buildWhere(ctx, info.where)
+}
 
-	// ORDER BY
-	if len(info.orders) > 0 {
-		ctx.AppendSql(" ORDER BY ")
-		for i, order := range info.orders {
-			if i > 0 {
-				ctx.AppendSql(",")
-			}
-			for j, col := range order.columns {
-				if j > 0 {
-					ctx.AppendSql(",")
-				}
-				if order.table != nil {
-					ctx.AppendSqlF("[%s].", order.table.Prefix())
-				}
-				ctx.AppendSqlF("[%s]", col)
-			}
-			ctx.AppendSqlF(" %s", order.st)
-		}
+// BuildDelete build query string and parameters for delete action, like: DELETE a FROM a JOIN b ON ...
+func (this *mssqlBuilder) BuildDelete(ctx *BuildContext, info *DeleteInfo) error {
+	if len(info.joins) == 0 {
+		return this.Builder.BuildDelete(ctx, info)
 	}
+	return this.buildDeleteJoins(ctx, info)
+}
 
-	// LIMIT
-	if info.skip != 0 || info.take != 0 {
-		ctx.AppendSqlF(" OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", info.skip, info.take)
+// Quote returns quoted identifier, like [ID]
+func (this *mssqlBuilder) Quote(name string) string {
+	return "[" + name + "]"
+}
+
+// Page returns OFFSET/FETCH clause, FETCH is omitted if take is 0
+func (this *mssqlBuilder) Page(skip, take int32) string {
+	if take == 0 {
+		return fmt.Sprintf(" OFFSET %d ROWS", skip)
 	}
+	return fmt.Sprintf(" OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", skip, take)
+}
 
-	return nil
+// MaxParams returns maximum count of parameters in a statement
+func (this *mssqlBuilder) MaxParams() int {
+	return 2100
 }
 
-func (this *mssqlBuilder) BuildFilters(ctx *buildContext, filters Filters) error {
-	switch v := filters.(type) {
-	case *basicFilters:
-		for i, f := range v.items {
-			if i > 0 {
-				ctx.AppendSql(" AND ")
-			}
-			err := this.BuildFilter(ctx, f)
-			if err != nil {
-				return err
-			}
-		}
-	case *notFilters:
-		ctx.AppendSql("NOT(")
-		err := this.BuildFilters(ctx, v.inner)
-		if err != nil {
-			return err
-		}
-		ctx.AppendSql(")")
-	case *andFilters:
-		ctx.AppendSql("(")
-		this.BuildFilters(ctx, v.left)
-		ctx.AppendSql(") AND (")
-		this.BuildFilters(ctx, v.right)
-		ctx.AppendSql(")")
-	case *orFilters:
-		ctx.AppendSql("(")
-		err := this.BuildFilters(ctx, v.left)
-		if err != nil {
-			return err
-		}
-		ctx.AppendSql(") OR (")
-		err = this.BuildFilters(ctx, v.right)
-		if err != nil {
-			return err
-		}
-		ctx.AppendSql(")")
-	}
+/********** mssql2005Builder **********/
 
-	return nil
+// SQL Server 2005+ builder
+type mssql2005Builder struct {
+	mssqlBuilder
 }
 
-func (this *mssqlBuilder) BuildFilter(ctx *buildContext, filter interface{}) (err error) {
-	switch f := filter.(type) {
-	case *oneColumnFilter:
-		err = this.BuildOneColumnFilter(ctx, f)
-	case *twoColumnFilter:
-		err = this.BuildTwoColumnFilter(ctx, f)
-	case *exprFilter:
-		ctx.AppendSql(f.expr)
-	default:
-		err = fmt.Errorf("invalid filter: %v", filter)
-	}
-	return
+func newMssql2005Builder() *mssql2005Builder {
+	b := &mssql2005Builder{}
+	b.Builder = NewBuilder(b)
+	b.NoRecursive = true
+	b.Returning = RETURNING_OUTPUT
+	b.MaxInsertRows = 1000
+	return b
 }
 
-func (this *mssqlBuilder) BuildOneColumnFilter(ctx *buildContext, f *oneColumnFilter) error {
-	if f.table != nil {
-		ctx.AppendSqlF("[%s].", f.table.Prefix())
+// BuildInsert build query string and parameters for insert action, multiple rows are inserted with
+// INSERT ... SELECT ... UNION ALL SELECT ..., since multi-row VALUES is not supported before SQL Server 2008.
+func (this *mssql2005Builder) BuildInsert(ctx *BuildContext, info *InsertInfo) error {
+	if info.rows == nil {
+		return this.mssqlBuilder.BuildInsert(ctx, info)
 	}
 
-	switch f.ft {
-	case FILTER_NE:
-		if f.value == nil {
-			ctx.AppendSqlF("[%s] IS NOT NULL", f.column)
-		} else {
-			ctx.AppendSqlF("[%s]<>?", f.column)
-			ctx.AddParam(f.value)
-		}
-	case FILTER_LT:
-		ctx.AppendSqlF("[%s]<?", f.column)
-		ctx.AddParam(f.value)
-	case FILTER_GT:
-		ctx.AppendSqlF("[%s]>?", f.column)
-		ctx.AddParam(f.value)
-	case FILTER_LTE:
-		ctx.AppendSqlF("[%s]<=?", f.column)
-		ctx.AddParam(f.value)
-	case FILTER_GTE:
-		ctx.AppendSqlF("[%s]>=?", f.column)
-		ctx.AddParam(f.value)
-	case FILTER_IN:
-		ctx.AppendSqlF("[%s] IN(%s)", f.column, f.value)
-	case FILTER_LK:
-		ctx.AppendSqlF("[%s] LIKE '%' + ? + '%'", f.column)
-		ctx.AddParam(f.value)
-	default:
-		if f.value == nil {
-			ctx.AppendSqlF("[%s] IS NULL", f.column)
-		} else {
-			ctx.AppendSqlF("[%s]=?", f.column)
-			ctx.AddParam(f.value)
+	ctx.AppendSql("INSERT INTO ", this.Quote(info.table), "(")
+	for i, col := range info.columns {
+		if i > 0 {
+			ctx.AppendSql(",")
 		}
+		ctx.AppendSql(this.Quote(col))
 	}
+	ctx.AppendSql(")")
 
+	for i, row := range info.rows {
+		if i > 0 {
+			ctx.AppendSql(" UNION ALL")
+		}
+		ctx.AppendSql(" SELECT ")
+		ctx.AppendParam(row...)
+	}
 	return nil
 }
 
-func (this *mssqlBuilder) BuildTwoColumnFilter(ctx *buildContext, f *twoColumnFilter) error {
-	switch f.ft {
-	case FILTER_NE:
-		ctx.AppendSqlF("[%s].[%s]<>[%s].[%s]", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	case FILTER_LT:
-		ctx.AppendSqlF("[%s].[%s]<[%s].[%s]", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	case FILTER_GT:
-		ctx.AppendSqlF("[%s].[%s]>[%s].[%s]", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	case FILTER_LTE:
-		ctx.AppendSqlF("[%s].[%s]<=[%s].[%s]", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	case FILTER_GTE:
-		ctx.AppendSqlF("[%s].[%s]>=[%s].[%s]", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	case FILTER_IN:
-		return fmt.Errorf("invalid filterType: IN")
-	case FILTER_LK:
-		return fmt.Errorf("invalid filterType: LK")
-	default:
-		ctx.AppendSqlF("[%s].[%s]=[%s].[%s]", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
+// BuildUpsert build query string and parameters for upsert action, MERGE is not supported before SQL Server 2008,
+// so the row is updated with locks held, and inserted if no row is updated, like:
+// UPDATE a WITH (UPDLOCK, HOLDLOCK) SET ... WHERE ...; IF @@ROWCOUNT=0 INSERT INTO a(...) VALUES(...);
+func (this *mssql2005Builder) BuildUpsert(ctx *BuildContext, info *UpsertInfo) error {
+	if err := this.checkUpsert(info); err != nil {
+		return err
 	}
 
-	return nil
-}
-
-/********** mssqlBuilder **********/
+	table := this.Quote(info.table)
+	ctx.AppendSql("UPDATE ", table, " WITH (UPDLOCK, HOLDLOCK) SET")
+	this.buildSetValues(ctx, info.updates, "", false)
+	ctx.AppendSql(" WHERE ")
+	for i, k := range info.keys {
+		if i > 0 {
+			ctx.AppendSql(" AND ")
+		}
+		ctx.AppendSql(this.Quote(k), "=")
+		ctx.AppendParam(info.values[k])
+	}
 
-// SQL Server 2005+ builder
-type mssql2005Builder struct {
-	mssqlBuilder
+	ctx.AppendSql("; IF @@ROWCOUNT=0 INSERT INTO ", table, "(")
+	this.buildInsertValues(ctx, info.values, nil)
+	ctx.AppendSql(";")
+	return nil
 }
 
 // BuildSelect build query string and parameters for select action
-func (this *mssql2005Builder) BuildSelect(ctx *buildContext, info *selectInfo) error {
+func (this *mssql2005Builder) BuildSelect(ctx *BuildContext, info *SelectInfo) error {
+	// WITH must be at the beginning of the statement, so it can't be wrapped by paging
+	if err := this.buildWith(ctx, info.ctes); err != nil {
+		return err
+	}
+
 	if info.skip == 0 {
 		return this.buildSelectNoPage(ctx, info)
 	} else {
@@ -316,8 +203,7 @@ func (this *mssql2005Builder) BuildSelect(ctx *buildContext, info *selectInfo) e
 	}
 }
 
-// BuildSelect build query string and parameters for select action
-func (this *mssql2005Builder) buildSelectNoPage(ctx *buildContext, info *selectInfo) error {
+func (this *mssql2005Builder) buildSelectNoPage(ctx *BuildContext, info *SelectInfo) error {
 	ctx.AppendSql("SELECT ")
 
 	if info.distinct {
@@ -329,221 +215,120 @@ func (this *mssql2005Builder) buildSelectNoPage(ctx *buildContext, info *selectI
 		ctx.AppendSqlF("TOP %d ", info.take)
 	}
 
-	// SELECT
-	for i, c := range info.columns {
-		if i > 0 {
-			ctx.AppendSql(",")
-		}
-
-		switch v := c.(type) {
-		case *normalColumn:
-			if v.table != nil {
-				ctx.AppendSqlF("[%s].", v.table.Prefix())
-			}
-			ctx.AppendSqlF("[%s]", v.column)
-			if v.alias != "" {
-				ctx.AppendSql(" AS ", v.alias)
-			}
-		case *exprColumn:
-			ctx.AppendSql(v.expr)
-			if v.alias != "" {
-				ctx.AppendSql(" AS ", v.alias)
-			}
-		}
+	this.buildColumns(ctx, info.columns)
+	if err := this.BuildBody(ctx, info); err != nil {
+		return err
 	}
+	this.buildOrders(ctx, info.orders)
 
-	// FROM
-	ctx.AppendSqlF(" FROM [%s]", info.table.Name())
-	if info.table.Alias() != "" {
-		ctx.AppendSql(" AS ", info.table.Alias())
-	}
+	return nil
+}
 
-	// JOIN
-	for _, j := range info.joins {
-		ctx.AppendSqlF(" %s [%s]", j.jt, j.t.Name())
-		if j.t.Alias() != "" {
-			ctx.AppendSql(" AS ", j.t.Alias())
-		}
-		ctx.AppendSql(" ON ")
-		this.BuildFilters(ctx, j.on)
+func (this *mssql2005Builder) buildSelectPage(ctx *BuildContext, info *SelectInfo) error {
+	ctx.AppendSql("SELECT ")
+	if err := this.buildOuterColumns(ctx, info.columns); err != nil {
+		return err
 	}
+	ctx.AppendSql(" FROM (SELECT ")
 
-	if info.where != nil {
-		ctx.AppendSql(" WHERE ")
-		this.BuildFilters(ctx, info.where)
+	if info.distinct {
+		ctx.AppendSql("DISTINCT ")
 	}
 
-	// GROUP BY
-	if len(info.groups) > 0 {
-		ctx.AppendSql(" GROUP BY ")
-		for i, g := range info.groups {
-			if i > 0 {
-				ctx.AppendSql(",")
-			}
-			for j, col := range g.columns {
-				if j > 0 {
-					ctx.AppendSql(",")
-				}
-				if g.table != nil {
-					ctx.AppendSqlF("[%s].", g.table.Prefix())
-				}
-				ctx.AppendSqlF("[%s]", col)
-			}
-		}
-
-		if info.having != nil {
-			ctx.AppendSql(" HAVING ")
-			this.BuildFilters(ctx, info.having)
-		}
-	}
+	this.buildColumns(ctx, info.columns)
 
 	// ORDER BY
+	ctx.AppendSql(",ROW_NUMBER() OVER(")
 	if len(info.orders) > 0 {
-		ctx.AppendSql(" ORDER BY ")
-		for i, order := range info.orders {
-			if i > 0 {
-				ctx.AppendSql(",")
-			}
-			for j, col := range order.columns {
-				if j > 0 {
-					ctx.AppendSql(",")
-				}
-				if order.table != nil {
-					ctx.AppendSqlF("[%s].", order.table.Prefix())
-				}
-				ctx.AppendSqlF("[%s]", col)
-			}
-			ctx.AppendSqlF(" %s", order.st)
-		}
+		ctx.AppendSql("ORDER BY ")
+		this.buildSorters(ctx, info.orders)
 	}
+	ctx.AppendSql(") AS _N")
 
-	return nil
-}
+	if err := this.BuildBody(ctx, info); err != nil {
+		return err
+	}
 
-// BuildSelect build query string and parameters for select action
-func (this *mssql2005Builder) buildSelectPage(ctx *buildContext, info *selectInfo) error {
-	ctx.AppendSql("SELECT ")
+	ctx.AppendSql(") AS _T")
+	this.buildRowRange(ctx, info.skip, info.take)
 
-	// SELECT
-	for i, c := range info.columns {
-		if i > 0 {
-			ctx.AppendSql(",")
-		}
+	return nil
+}
 
-		switch v := c.(type) {
-		case *normalColumn:
-			if v.table != nil {
-				ctx.AppendSqlF("[%s].", v.table.Prefix())
-			}
-			ctx.AppendSqlF("[%s]", v.column)
-			if v.alias != "" {
-				ctx.AppendSql(" AS ", v.alias)
-			}
-		case *exprColumn:
-			ctx.AppendSql(v.expr)
-			if v.alias != "" {
-				ctx.AppendSql(" AS ", v.alias)
-			}
-		}
+// buildRowRange writes condition of row numbers of the paging wrapper, there is no upper bound if take is 0
+func (this *mssql2005Builder) buildRowRange(ctx *BuildContext, skip, take int32) {
+	ctx.AppendSqlF(" WHERE _N>%d", skip)
+	if take > 0 {
+		ctx.AppendSqlF(" AND _N<=%d", skip+take)
 	}
+}
 
-	ctx.AppendSql(" FROM (SELECT ")
-
-	if info.distinct {
-		ctx.AppendSql("DISTINCT ")
+// BuildCompound build query string and parameters for compound select action,
+// paging is done by wrapping the whole compound query like BuildSelect.
+func (this *mssql2005Builder) BuildCompound(ctx *BuildContext, info *CompoundInfo) error {
+	if info.skip == 0 && info.take == 0 {
+		return this.mssqlBuilder.BuildCompound(ctx, info)
 	}
 
-	// SELECT
-	for i, c := range info.columns {
-		if i > 0 {
-			ctx.AppendSql(",")
+	if info.skip == 0 {
+		ctx.AppendSqlF("SELECT TOP %d * FROM (", info.take)
+		if err := this.buildCompoundQueries(ctx, info, true); err != nil {
+			return err
 		}
+		ctx.AppendSql(") AS _C")
+		this.buildOrders(ctx, info.orders)
+		return nil
+	}
 
-		switch v := c.(type) {
-		case *normalColumn:
-			if v.table != nil {
-				ctx.AppendSqlF("[%s].", v.table.Prefix())
-			}
-			ctx.AppendSqlF("[%s]", v.column)
-			if v.alias != "" {
-				ctx.AppendSql(" AS ", v.alias)
-			}
-		case *exprColumn:
-			ctx.AppendSql(v.expr)
-			if v.alias != "" {
-				ctx.AppendSql(" AS ", v.alias)
-			}
-		}
+	sc, ok := info.queries[0].(*selectContext)
+	if !ok {
+		return fmt.Errorf("invalid subquery: %v", info.queries[0])
 	}
 
-	// ORDER BY
-	ctx.AppendSql(",ROW_NUMBER() OVER(")
+	ctx.AppendSql("SELECT ")
+	if err := this.buildOuterColumns(ctx, sc.info.columns); err != nil {
+		return err
+	}
+	ctx.AppendSql(" FROM (SELECT _C.*,ROW_NUMBER() OVER(")
 	if len(info.orders) > 0 {
 		ctx.AppendSql("ORDER BY ")
-		for i, order := range info.orders {
-			if i > 0 {
-				ctx.AppendSql(",")
-			}
-			for j, col := range order.columns {
-				if j > 0 {
-					ctx.AppendSql(",")
-				}
-				if order.table != nil {
-					ctx.AppendSqlF("[%s].", order.table.Prefix())
-				}
-				ctx.AppendSqlF("[%s]", col)
-			}
-			ctx.AppendSqlF(" %s", order.st)
-		}
+		this.buildSorters(ctx, info.orders)
 	}
-	ctx.AppendSql(") AS _N")
-
-	// FROM
-	ctx.AppendSqlF(" FROM [%s]", info.table.Name())
-	if info.table.Alias() != "" {
-		ctx.AppendSql(" AS ", info.table.Alias())
+	ctx.AppendSql(") AS _N FROM (")
+	if err := this.buildCompoundQueries(ctx, info, true); err != nil {
+		return err
 	}
+	ctx.AppendSql(") AS _C) AS _T")
+	this.buildRowRange(ctx, info.skip, info.take)
 
-	// JOIN
-	for _, j := range info.joins {
-		ctx.AppendSqlF(" %s [%s]", j.jt, j.t.Name())
-		if j.t.Alias() != "" {
-			ctx.AppendSql(" AS ", j.t.Alias())
-		}
-		ctx.AppendSql(" ON ")
-		this.BuildFilters(ctx, j.on)
-	}
+	return nil
+}
 
-	if info.where != nil {
-		ctx.AppendSql(" WHERE ")
-		this.BuildFilters(ctx, info.where)
-	}
+// buildOuterColumns writes columns of the paging wrapper, which can only reference columns of the inner query by their names
+func (this *mssql2005Builder) buildOuterColumns(ctx *BuildContext, columns []column) error {
+	for i, c := range columns {
+		if i > 0 {
+			ctx.AppendSql(",")
+		}
 
-	// GROUP BY
-	if len(info.groups) > 0 {
-		ctx.AppendSql(" GROUP BY ")
-		for i, g := range info.groups {
-			if i > 0 {
-				ctx.AppendSql(",")
-			}
-			for j, col := range g.columns {
-				if j > 0 {
-					ctx.AppendSql(",")
-				}
-				if g.table != nil {
-					ctx.AppendSqlF("[%s].", g.table.Prefix())
-				}
-				ctx.AppendSqlF("[%s]", col)
-			}
+		if alias := c.Alias(); alias != "" {
+			ctx.AppendSql(this.Quote(alias))
+			continue
 		}
 
-		if info.having != nil {
-			ctx.AppendSql(" HAVING ")
-			this.BuildFilters(ctx, info.having)
+		switch v := c.(type) {
+		case *normalColumn:
+			ctx.AppendSql(this.Quote(v.column))
+		case *exprColumn:
+			// raw expression like COUNT(*) can't be referenced by the wrapper
+			return fmt.Errorf("expression column [%s] must have an alias for paging", v.expr)
+		case *typedColumn:
+			ce, ok := v.expr.(*colExpr)
+			if !ok {
+				return fmt.Errorf("expression column must have an alias for paging")
+			}
+			ctx.AppendSql(this.Quote(ce.column))
 		}
 	}
-
-	ctx.AppendSqlF(") AS _T WHERE _N>%d AND _N<=%d", info.skip, info.skip+info.take)
-
 	return nil
 }
@@ -2,297 +2,77 @@ package gsd
 
 import (
 	"fmt"
-	"strings"
 )
 
+/********** mysqlBuilder **********/
+
 type mysqlBuilder struct {
+	*Builder
 }
 
-// BuildInsert build query string and parameters for insert action
-func (this *mysqlBuilder) BuildInsert(ctx *buildContext, info *insertInfo) error {
-	ctx.AppendSqlF("INSERT INTO `%s`(", info.table)
-
-	first := true
-	for k, v := range info.values {
-		if first {
-			first = false
-		} else {
-			ctx.AppendSql(",")
-		}
-		ctx.AppendSqlF("`%s`", k)
-		ctx.AddParam(v)
-	}
-
-	ctx.AppendSql(") VALUES(?", strings.Repeat(",?", len(info.values)-1), ")")
-
-	return nil
+func newMysqlBuilder() *mysqlBuilder {
+	b := &mysqlBuilder{}
+	b.Builder = NewBuilder(b)
+	b.Returning = RETURNING_NONE
+	return b
 }
 
-// BuildUpdate build query string and parameters for update action
-func (this *mysqlBuilder) BuildUpdate(ctx *buildContext, info *updateInfo) error {
-	ctx.AppendSqlF("UPDATE `%s` SET", info.table)
-
-	first := true
-	for k, v := range info.values {
-		if first {
-			first = false
-		} else {
-			ctx.AppendSql(",")
-		}
-
-		switch v.ut {
-		case UPDATE_INC:
-			ctx.AppendSqlF(" `%s`=`%s`+?", k, k)
-			ctx.AddParam(v.val)
-		case UPDATE_XP:
-			ctx.AppendSqlF(" `%s`=%s", k, v.val)
-		default:
-			ctx.AppendSqlF(" `%s`=?", k)
-			ctx.AddParam(v.val)
-		}
-	}
-
-	if info.where != nil {
-		ctx.AppendSql(" WHERE ")
-		this.BuildFilters(ctx, info.where)
+// BuildUpsert build query string and parameters for upsert action with INSERT ... ON DUPLICATE KEY UPDATE,
+// conflict keys are decided by unique indexes of the table.
+func (this *mysqlBuilder) BuildUpsert(ctx *BuildContext, info *UpsertInfo) error {
+	if len(info.updates) == 0 {
+		return fmt.Errorf("update values of upsert are not set")
 	}
 
+	ctx.AppendSql("INSERT INTO ", this.Quote(info.table), "(")
+	this.buildInsertValues(ctx, info.values, nil)
+	ctx.AppendSql(" ON DUPLICATE KEY UPDATE")
+	this.buildSetValues(ctx, info.updates, "", false)
 	return nil
 }
 
-// BuildSelect build query string and parameters for select action
-func (this *mysqlBuilder) BuildSelect(ctx *buildContext, info *selectInfo) error {
-	ctx.AppendSql("SELECT ")
-
-	if info.distinct {
-		ctx.AppendSql("DISTINCT ")
+// BuildUpdate build query string and parameters for update action, like: UPDATE a JOIN b ON ... SET a.x=...
+func (this *mysqlBuilder) BuildUpdate(ctx *BuildContext, info *UpdateInfo) error {
+	if len(info.joins) == 0 {
+		return this.Builder.BuildUpdate(ctx, info)
 	}
 
-	// SELECT
-	for i, c := range info.columns {
-		if i > 0 {
-			ctx.AppendSql(",")
-		}
-
-		switch v := c.(type) {
-		case *normalColumn:
-			if v.table != nil {
-				ctx.AppendSqlF("`%s`.", v.table.Prefix())
-			}
-			ctx.AppendSqlF("`%s`", v.column)
-			if v.alias != "" {
-				ctx.AppendSql(" AS ", v.alias)
-			}
-		case *exprColumn:
-			ctx.AppendSql(v.expr)
-			if v.alias != "" {
-				ctx.AppendSql(" AS ", v.alias)
-			}
-		}
-	}
-
-	// FROM
-	ctx.AppendSqlF(" FROM `%s`", info.table.Name())
-	if info.table.Alias() != "" {
-		ctx.AppendSql(" AS ", info.table.Alias())
-	}
-
-	// JOIN
-	for _, j := range info.joins {
-		ctx.AppendSqlF(" %s `%s`", j.jt, j.t.Name())
-		if j.t.Alias() != "" {
-			ctx.AppendSql(" AS ", j.t.Alias())
-		}
-		ctx.AppendSql(" ON ")
-		this.BuildFilters(ctx, j.on)
-	}
-
-	if info.where != nil {
-		ctx.AppendSql(" WHERE ")
-		this.BuildFilters(ctx, info.where)
-	}
-
-	// GROUP BY
-	if len(info.groups) > 0 {
-		ctx.AppendSql(" GROUP BY ")
-		for i, g := range info.groups {
-			if i > 0 {
-				ctx.AppendSql(",")
-			}
-			for j, col := range g.columns {
-				if j > 0 {
-					ctx.AppendSql(",")
-				}
-				if g.table != nil {
-					ctx.AppendSqlF("`%s`.", g.table.Prefix())
-				}
-				ctx.AppendSqlF("`%s`", col)
-			}
-		}
-
-		if info.having != nil {
-			ctx.AppendSql(" HAVING ")
-			this.BuildFilters(ctx, info.having)
-		}
+	if err := this.checkReturning(info.returning); err != nil {
+		return err
 	}
 
-	// ORDER BY
-	if len(info.orders) > 0 {
-		ctx.AppendSql(" ORDER BY ")
-		for i, order := range info.orders {
-			if i > 0 {
-				ctx.AppendSql(",")
-			}
-			for j, col := range order.columns {
-				if j > 0 {
-					ctx.AppendSql(",")
-				}
-				if order.table != nil {
-					ctx.AppendSqlF("`%s`.", order.table.Prefix())
-				}
-				ctx.AppendSqlF("`%s`", col)
-			}
-			ctx.AppendSqlF(" %s", order.st)
-		}
+	table := this.Quote(info.table)
+	ctx.AppendSql("UPDATE ", table)
+	if err := this.buildJoins(ctx, info.joins); err != nil {
+		return err
 	}
-
-	// LIMIT
-	if info.skip != 0 || info.take != 0 {
-		ctx.AppendSqlF(" LIMIT %d,%d", info.skip, info.take)
-	}
-
-	return nil
-}
-
-// BuildDelete build query string and parameters for delete action
-func (this *mysqlBuilder) BuildDelete(ctx *buildContext, info *deleteInfo) error {
-	ctx.AppendSqlF("DELETE FROM `%s`", info.table)
-
-	if info.where != nil {
-		ctx.AppendSql(" WHERE ")
-		this.BuildFilters(ctx, info.where)
-	}
-
-	return nil
+	ctx.AppendSql(" SET")
+	this.buildSetValues(ctx, info.values, table+".", true)
+	return this.buildWhere(ctx, info.where)
 }
 
-func (this *mysqlBuilder) BuildFilters(ctx *buildContext, filters Filters) error {
-	switch v := filters.(type) {
-	case *basicFilters:
-		for i, f := range v.items {
-			if i > 0 {
-				ctx.AppendSql(" AND ")
-			}
-			err := this.BuildFilter(ctx, f)
-			if err != nil {
-				return err
-			}
-		}
-	case *notFilters:
-		ctx.AppendSql("NOT(")
-		err := this.BuildFilters(ctx, v.inner)
-		if err != nil {
-			return err
-		}
-		ctx.AppendSql(")")
-	case *andFilters:
-		ctx.AppendSql("(")
-		this.BuildFilters(ctx, v.left)
-		ctx.AppendSql(") AND (")
-		this.BuildFilters(ctx, v.right)
-		ctx.AppendSql(")")
-	case *orFilters:
-		ctx.AppendSql("(")
-		err := this.BuildFilters(ctx, v.left)
-		if err != nil {
-			return err
-		}
-		ctx.AppendSql(") OR (")
-		err = this.BuildFilters(ctx, v.right)
-		if err != nil {
-			return err
-		}
-		ctx.AppendSql(")")
+// BuildDelete build query string and parameters for delete action, like: DELETE a FROM a JOIN b ON ...
+func (this *mysqlBuilder) BuildDelete(ctx *BuildContext, info *DeleteInfo) error {
+	if len(info.joins) == 0 {
+		return this.Builder.BuildDelete(ctx, info)
 	}
-
-	return nil
+	return this.buildDeleteJoins(ctx, info)
 }
 
-func (this *mysqlBuilder) BuildFilter(ctx *buildContext, filter interface{}) (err error) {
-	switch f := filter.(type) {
-	case *oneColumnFilter:
-		err = this.BuildOneColumnFilter(ctx, f)
-	case *twoColumnFilter:
-		err = this.BuildTwoColumnFilter(ctx, f)
-	case *exprFilter:
-		ctx.AppendSql(f.expr)
-	default:
-		err = fmt.Errorf("invalid filter: %v", filter)
-	}
-	return
+// Quote returns quoted identifier, like `ID`
+func (this *mysqlBuilder) Quote(name string) string {
+	return "`" + name + "`"
 }
 
-func (this *mysqlBuilder) BuildOneColumnFilter(ctx *buildContext, f *oneColumnFilter) error {
-	if f.table != nil {
-		ctx.AppendSqlF("`%s`.", f.table.Prefix())
-	}
-
-	switch f.ft {
-	case FILTER_NE:
-		if f.value == nil {
-			ctx.AppendSqlF("`%s` IS NOT NULL", f.column)
-		} else {
-			ctx.AppendSqlF("`%s`<>?", f.column)
-			ctx.AddParam(f.value)
-		}
-	case FILTER_LT:
-		ctx.AppendSqlF("`%s`<?", f.column)
-		ctx.AddParam(f.value)
-	case FILTER_GT:
-		ctx.AppendSqlF("`%s`>?", f.column)
-		ctx.AddParam(f.value)
-	case FILTER_LTE:
-		ctx.AppendSqlF("`%s`<=?", f.column)
-		ctx.AddParam(f.value)
-	case FILTER_GTE:
-		ctx.AppendSqlF("`%s`>=?", f.column)
-		ctx.AddParam(f.value)
-	case FILTER_IN:
-		ctx.AppendSqlF("`%s` IN(%s)", f.column, f.value)
-	case FILTER_LK:
-		ctx.AppendSqlF("`%s` LIKE CONCAT('%', ?, '%')", f.column)
-		ctx.AddParam(f.value)
-	default:
-		if f.value == nil {
-			ctx.AppendSqlF("`%s` IS NULL", f.column)
-		} else {
-			ctx.AppendSqlF("`%s`=?", f.column)
-			ctx.AddParam(f.value)
-		}
+// Page returns LIMIT clause, MySQL requires count of rows, so the max value is used if take is 0
+func (this *mysqlBuilder) Page(skip, take int32) string {
+	if take == 0 {
+		return fmt.Sprintf(" LIMIT %d,18446744073709551615", skip)
 	}
-
-	return nil
+	return fmt.Sprintf(" LIMIT %d,%d", skip, take)
 }
 
-func (this *mysqlBuilder) BuildTwoColumnFilter(ctx *buildContext, f *twoColumnFilter) error {
-	switch f.ft {
-	case FILTER_NE:
-		ctx.AppendSqlF("`%s`.`%s`<>`%s`.`%s`", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	case FILTER_LT:
-		ctx.AppendSqlF("`%s`.`%s`<`%s`.`%s`", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	case FILTER_GT:
-		ctx.AppendSqlF("`%s`.`%s`>`%s`.`%s`", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	case FILTER_LTE:
-		ctx.AppendSqlF("`%s`.`%s`<=`%s`.`%s`", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	case FILTER_GTE:
-		ctx.AppendSqlF("`%s`.`%s`>=`%s`.`%s`", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	case FILTER_IN:
-		return fmt.Errorf("invalid filterType: IN")
-	case FILTER_LK:
-		return fmt.Errorf("invalid filterType: LK")
-	default:
-		ctx.AppendSqlF("`%s`.`%s`=`%s`.`%s`", f.table1.Prefix(), f.column1, f.table2.Prefix(), f.column2)
-	}
-
-	return nil
+// MaxParams returns maximum count of parameters in a statement
+func (this *mysqlBuilder) MaxParams() int {
+	return 65535
 }
@@ -0,0 +1,151 @@
+package gsd
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+)
+
+/********** balancePolicy **********/
+
+// balancePolicy decides which replica is used for reading
+type balancePolicy int8
+
+const (
+	balanceRoundRobin balancePolicy = iota
+	balanceRandom
+	balanceLeastConn
+)
+
+func parseBalancePolicy(s string) (balancePolicy, error) {
+	switch s {
+	case "", "round-robin":
+		return balanceRoundRobin, nil
+	case "random":
+		return balanceRandom, nil
+	case "least-conn":
+		return balanceLeastConn, nil
+	default:
+		return 0, fmt.Errorf("not supported replica policy: %s", s)
+	}
+}
+
+/********** pool **********/
+
+// pool holds connections of a database, it is replaced as a whole when the database is reloaded
+type pool struct {
+	db       *sql.DB
+	replicas []*sql.DB
+	stmts    []*stmtCache // statement caches of the primary and replicas, nil if StmtCacheSize is not set
+	policy   balancePolicy
+	next     uint32 // counter for round-robin policy
+	batch    int    // max rows of a batch insert statement
+	b        Dialect
+	cfg      *Config
+}
+
+func newPool(cfg *Config) (p *pool, err error) {
+	d, ok := getDialect(cfg.Provider)
+	if !ok {
+		return nil, fmt.Errorf("not supported database provider: %s", cfg.Provider)
+	}
+
+	connStr, ok := cfg.Settings["ConnString"]
+	if !ok {
+		return nil, fmt.Errorf("connection string of database [%s] is not configured", cfg.Name)
+	}
+
+	p = &pool{b: d, cfg: cfg, batch: cfg.Settings.Int("BatchSize", DEFAULT_BATCH_SIZE)}
+	if p.policy, err = parseBalancePolicy(cfg.Settings.String("ReplicaPolicy", "")); err != nil {
+		return nil, err
+	}
+
+	if p.db, err = newDB(cfg, connStr); err != nil {
+		return nil, err
+	}
+
+	for _, r := range cfg.Replicas {
+		var db *sql.DB
+		if db, err = newDB(cfg, r); err != nil {
+			p.close()
+			return nil, err
+		}
+		p.replicas = append(p.replicas, db)
+	}
+
+	if size := cfg.Settings.Int("StmtCacheSize", 0); size > 0 {
+		p.stmts = append(p.stmts, newStmtCache(p.db, size))
+		for _, r := range p.replicas {
+			p.stmts = append(p.stmts, newStmtCache(r, size))
+		}
+	}
+	return
+}
+
+// primary returns executor of the primary
+func (this *pool) primary() executor {
+	if this.stmts != nil {
+		return this.stmts[0]
+	}
+	return this.db
+}
+
+// replica returns executor of the i-th replica
+func (this *pool) replica(i int) executor {
+	if this.stmts != nil {
+		return this.stmts[i+1]
+	}
+	return this.replicas[i]
+}
+
+// transaction returns executor of tx which is started on the primary
+func (this *pool) transaction(tx *sql.Tx) executor {
+	if this.stmts != nil {
+		return newTxStmts(tx, this.stmts[0])
+	}
+	return tx
+}
+
+// reader returns a replica chosen by the policy, or the primary if there are no replicas
+func (this *pool) reader() executor {
+	switch n := len(this.replicas); {
+	case n == 0:
+		return this.primary()
+	case n == 1:
+		return this.replica(0)
+	}
+
+	switch this.policy {
+	case balanceRandom:
+		return this.replica(rand.Intn(len(this.replicas)))
+	case balanceLeastConn:
+		index, conns := 0, this.replicas[0].Stats().InUse
+		for i, r := range this.replicas[1:] {
+			if c := r.Stats().InUse; c < conns {
+				index, conns = i+1, c
+			}
+		}
+		return this.replica(index)
+	default:
+		i := atomic.AddUint32(&this.next, 1)
+		return this.replica(int(i % uint32(len(this.replicas))))
+	}
+}
+
+// close closes cached statements, the primary and all replicas
+func (this *pool) close() (err error) {
+	for _, s := range this.stmts {
+		s.close()
+	}
+
+	if this.db != nil {
+		err = this.db.Close()
+	}
+	for _, r := range this.replicas {
+		if e := r.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
@@ -0,0 +1,101 @@
+package gsd
+
+import (
+	"context"
+	"testing"
+)
+
+// openReplicas opens a database with a primary and 2 replicas, which are named with prefix
+func openReplicas(t *testing.T, prefix, policy string) (db *Database, primary *testServer, replicas []*testServer) {
+	primary = newTestServer(t, prefix)
+	replicas = []*testServer{newTestServer(t, prefix+"-1"), newTestServer(t, prefix+"-2")}
+	db, err := OpenConfig(&Config{
+		Name:     prefix,
+		Provider: "sqlite",
+		Driver:   "gsdtest",
+		Settings: SettingMap{"ConnString": prefix, "ReplicaPolicy": policy},
+		Replicas: []string{prefix + "-1", prefix + "-2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return
+}
+
+func selectID(t *testing.T, s Session) {
+	var id int
+	if err := s.Select(C(false).AddE("ID", "")).From(T("A")).Row().Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplicaRoundRobin(t *testing.T) {
+	db, primary, replicas := openReplicas(t, "round-robin", "")
+	for i := 0; i < 4; i++ {
+		selectID(t, db)
+	}
+	if _, err := db.Insert("A").Values(InsertValues{"ID": 1}).Result(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, r := range replicas {
+		if n := len(r.Queries()); n != 2 {
+			t.Errorf("replica %d: expect 2 queries, got %d", i, n)
+		}
+	}
+	if n := len(primary.Queries()); n != 1 {
+		t.Errorf("only insert should go to the primary, got %d queries", n)
+	}
+}
+
+func TestReplicaRandom(t *testing.T) {
+	db, primary, replicas := openReplicas(t, "random", "random")
+	for i := 0; i < 10; i++ {
+		selectID(t, db)
+	}
+
+	if n := len(replicas[0].Queries()) + len(replicas[1].Queries()); n != 10 {
+		t.Errorf("expect 10 queries on replicas, got %d", n)
+	}
+	if n := len(primary.Queries()); n != 0 {
+		t.Errorf("select should not go to the primary, got %d queries", n)
+	}
+}
+
+func TestReplicaLeastConn(t *testing.T) {
+	db, _, replicas := openReplicas(t, "least-conn", "least-conn")
+
+	// hold a connection of the first replica, so the second one has less connections in use
+	conn, err := db.pool().replicas[0].Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for i := 0; i < 3; i++ {
+		selectID(t, db)
+	}
+	if n := len(replicas[1].Queries()); n != 3 {
+		t.Errorf("expect 3 queries on the idle replica, got %d", n)
+	}
+}
+
+func TestReplicaPrimary(t *testing.T) {
+	db, primary, replicas := openReplicas(t, "primary", "")
+	selectID(t, db.Primary())
+
+	if n := len(primary.Queries()); n != 1 {
+		t.Errorf("expect 1 query on the primary, got %d", n)
+	}
+	if n := len(replicas[0].Queries()) + len(replicas[1].Queries()); n != 0 {
+		t.Errorf("expect no query on replicas, got %d", n)
+	}
+}
+
+func TestReplicaPolicyInvalid(t *testing.T) {
+	_, err := OpenConfig(&Config{Name: "invalid", Provider: "sqlite", Driver: "gsdtest", Settings: SettingMap{"ConnString": "invalid", "ReplicaPolicy": "weighted"}})
+	if err == nil {
+		t.Error("expect error of invalid replica policy")
+	}
+}
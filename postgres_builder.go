@@ -0,0 +1,42 @@
+package gsd
+
+import (
+	"fmt"
+	"strconv"
+)
+
+/********** postgresBuilder **********/
+
+type postgresBuilder struct {
+	*Builder
+}
+
+func newPostgresBuilder() *postgresBuilder {
+	b := &postgresBuilder{}
+	b.Builder = NewBuilder(b)
+	b.Like = "ILIKE"
+	return b
+}
+
+// Quote returns quoted identifier, like "ID"
+func (this *postgresBuilder) Quote(name string) string {
+	return `"` + name + `"`
+}
+
+// Placeholder returns numbered placeholder, like $1
+func (this *postgresBuilder) Placeholder(index int) string {
+	return "$" + strconv.Itoa(index)
+}
+
+// Page returns LIMIT/OFFSET clause, LIMIT is omitted if take is 0
+func (this *postgresBuilder) Page(skip, take int32) string {
+	if take == 0 {
+		return fmt.Sprintf(" OFFSET %d", skip)
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", take, skip)
+}
+
+// MaxParams returns maximum count of parameters in a statement
+func (this *postgresBuilder) MaxParams() int {
+	return 65535
+}
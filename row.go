@@ -1,6 +1,7 @@
 package gsd
 
 import (
+	"context"
 	"database/sql"
 	// "fmt"
 	"reflect"
@@ -16,8 +17,17 @@ type Row interface {
 	ScanObj(obj interface{}) error
 }
 
+// newBuiltRow returns a row which queries with sql and parameters of ctx, or fails with err if building failed
+func newBuiltRow(exe executor, c context.Context, ctx *BuildContext, err error) Row {
+	if err != nil {
+		return &row{exe: exe, err: err}
+	}
+	return &row{exe: exe, ctx: c, sql: ctx.GetSql(), args: ctx.GetParams()}
+}
+
 type row struct {
 	exe  executor
+	ctx  context.Context
 	sql  string
 	args []interface{}
 	rows *sql.Rows
@@ -85,7 +95,7 @@ func (this *row) ScanObj(obj interface{}) error {
 
 func (this *row) prepareRows() error {
 	if this.err == nil && this.rows == nil {
-		this.rows, this.err = this.exe.Query(this.sql, this.args...)
+		this.rows, this.err = this.exe.QueryContext(this.ctx, this.sql, this.args...)
 	}
 	return this.err
 }
@@ -97,8 +107,17 @@ type Rows interface {
 	For(f func(r Row) error) error
 }
 
+// newBuiltRows returns rows which queries with sql and parameters of ctx, or fails with err if building failed
+func newBuiltRows(exe executor, c context.Context, ctx *BuildContext, err error) Rows {
+	if err != nil {
+		return &rows{exe: exe, err: err}
+	}
+	return &rows{exe: exe, ctx: c, sql: ctx.GetSql(), args: ctx.GetParams()}
+}
+
 type rows struct {
 	exe     executor
+	ctx     context.Context
 	sql     string
 	args    []interface{}
 	err     error
@@ -190,7 +209,7 @@ func (this *rows) scanObj(ti *typeInfo, obj interface{}) error {
 
 func (this *rows) prepareRows() error {
 	if this.err == nil && this.rows == nil {
-		this.rows, this.err = this.exe.Query(this.sql, this.args...)
+		this.rows, this.err = this.exe.QueryContext(this.ctx, this.sql, this.args...)
 	}
 	return this.err
 }
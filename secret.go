@@ -0,0 +1,103 @@
+package gsd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	_Resolvers = map[string]SecretResolver{
+		"env":  SecretResolverFunc(resolveEnv),
+		"file": SecretResolverFunc(resolveFile),
+	}
+	_ResolverLocker sync.RWMutex
+	_SecretRegexp   = regexp.MustCompile(`\$\$\{|\$\{([^}]+)\}`)
+)
+
+// SecretResolver resolves the value of ${scheme:key} in config settings, like ${file:/run/secrets/db}.
+// ${KEY} without scheme is resolved by the env resolver, and $${ is written as a literal ${.
+type SecretResolver interface {
+	Resolve(key string) (string, error)
+}
+
+// SecretResolverFunc is an adapter to allow the use of ordinary functions as SecretResolver.
+type SecretResolverFunc func(key string) (string, error)
+
+// Resolve calls f(key).
+func (f SecretResolverFunc) Resolve(key string) (string, error) {
+	return f(key)
+}
+
+// RegisterSecretResolver makes a resolver available by the scheme, it replaces the existing one with the same scheme.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	if r == nil {
+		panic("gsd: RegisterSecretResolver resolver is nil")
+	}
+
+	_ResolverLocker.Lock()
+	_Resolvers[scheme] = r
+	_ResolverLocker.Unlock()
+}
+
+// resolveSettings returns a copy of settings with all ${...} references resolved
+func resolveSettings(settings SettingMap) (SettingMap, error) {
+	m := make(SettingMap, len(settings))
+	for k, v := range settings {
+		var err error
+		if m[k], err = resolveValue(v); err != nil {
+			return nil, fmt.Errorf("failed to resolve setting [%s]: %v", k, err)
+		}
+	}
+	return m, nil
+}
+
+// resolveValue replaces all ${...} references in v, and unescapes $${ to ${
+func resolveValue(v string) (value string, err error) {
+	value = _SecretRegexp.ReplaceAllStringFunc(v, func(s string) string {
+		if err != nil {
+			return s
+		}
+		if s == "$${" {
+			return "${"
+		}
+
+		var value string
+		value, err = resolveSecret(s[2 : len(s)-1])
+		return value
+	})
+	return
+}
+
+func resolveSecret(ref string) (string, error) {
+	scheme, key := "env", ref
+	if i := strings.Index(ref, ":"); i > 0 {
+		scheme, key = ref[:i], ref[i+1:]
+	}
+
+	_ResolverLocker.RLock()
+	r, ok := _Resolvers[scheme]
+	_ResolverLocker.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("not supported secret scheme: %s", scheme)
+	}
+	return r.Resolve(key)
+}
+
+func resolveEnv(key string) (string, error) {
+	if v, ok := os.LookupEnv(key); ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("environment variable [%s] is not set", key)
+}
+
+func resolveFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	// secret files usually end with a line break
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,54 @@
+package gsd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveValue(t *testing.T) {
+	os.Setenv("GSD_TEST_PASSWORD", "secret")
+	defer os.Unsetenv("GSD_TEST_PASSWORD")
+
+	file := filepath.Join(t.TempDir(), "db")
+	if err := os.WriteFile(file, []byte("filed\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	RegisterSecretResolver("test", SecretResolverFunc(func(key string) (string, error) {
+		return "<" + key + ">", nil
+	}))
+
+	cases := map[string]string{
+		"user:${GSD_TEST_PASSWORD}@tcp(localhost)": "user:secret@tcp(localhost)",
+		"${file:" + file + "}":                     "filed",
+		"${test:a}-${test:b}":                      "<a>-<b>",
+		"a$${GSD_TEST_PASSWORD}":                   "a${GSD_TEST_PASSWORD}",
+		"$$${GSD_TEST_PASSWORD}":                   "$${GSD_TEST_PASSWORD}",
+		"$ and { are kept":                         "$ and { are kept",
+	}
+	for v, want := range cases {
+		got, err := resolveValue(v)
+		if err != nil {
+			t.Errorf("%s: %v", v, err)
+		} else if got != want {
+			t.Errorf("%s: got %s, want %s", v, got, want)
+		}
+	}
+}
+
+func TestResolveValueError(t *testing.T) {
+	for _, v := range []string{"${GSD_TEST_NOT_SET}", "${unknown:key}"} {
+		if _, err := resolveValue(v); err == nil {
+			t.Errorf("%s: expect error", v)
+		}
+	}
+
+	RegisterSecretResolver("fail", SecretResolverFunc(func(key string) (string, error) {
+		return "", fmt.Errorf("failed")
+	}))
+	cfg := &Config{Name: "test", Settings: SettingMap{"ConnString": "${fail:x}"}}
+	if _, err := cfg.resolve(); err == nil {
+		t.Error("expect error of resolving config")
+	}
+}
@@ -1,12 +1,13 @@
 package gsd
 
 import (
-// "database/sql"
+	"context"
 )
 
-/********** selectInfo **********/
+/********** SelectInfo **********/
 
-type selectInfo struct {
+type SelectInfo struct {
+	ctes     []*cte
 	table    Table
 	columns  []column
 	distinct bool
@@ -19,15 +20,30 @@ type selectInfo struct {
 	take     int32
 }
 
+// Distinct returns whether the action selects distinct rows
+func (this *SelectInfo) Distinct() bool {
+	return this.distinct
+}
+
+// Skip returns count of rows to skip
+func (this *SelectInfo) Skip() int32 {
+	return this.skip
+}
+
+// Take returns count of rows to take, 0 means all rows
+func (this *SelectInfo) Take() int32 {
+	return this.take
+}
+
 /********** selectContext **********/
 
 type selectContext struct {
 	exe  executor
-	b    builder
-	info *selectInfo
+	b    Dialect
+	info *SelectInfo
 }
 
-func newSelectContext(exe executor, b builder, info *selectInfo) *selectContext {
+func newSelectContext(exe executor, b Dialect, info *SelectInfo) *selectContext {
 	return &selectContext{
 		exe:  exe,
 		b:    b,
@@ -107,7 +123,7 @@ func (this *selectContext) OrderBy(s *Sorters) OrderByClause {
 }
 
 // func (this *selectContext) Result() (*SelectResult, error) {
-// 	ctx := newBuildContext()
+// 	ctx := newBuildContext(this.b)
 // 	err := this.b.BuildSelect(ctx, this.info)
 // 	if err != nil {
 // 		return nil, err
@@ -116,35 +132,23 @@ func (this *selectContext) OrderBy(s *Sorters) OrderByClause {
 // }
 
 func (this *selectContext) Row() Row {
-	ctx := newBuildContext()
-	if err := this.b.BuildSelect(ctx, this.info); err != nil {
-		return &row{
-			exe: this.exe,
-			err: err,
-		}
-	} else {
-		return &row{
-			exe:  this.exe,
-			sql:  ctx.GetSql(),
-			args: ctx.GetParams(),
-		}
-	}
+	return this.RowContext(context.Background())
 }
 
 func (this *selectContext) Rows() Rows {
-	ctx := newBuildContext()
-	if err := this.b.BuildSelect(ctx, this.info); err != nil {
-		return &rows{
-			exe: this.exe,
-			err: err,
-		}
-	} else {
-		return &rows{
-			exe:  this.exe,
-			sql:  ctx.GetSql(),
-			args: ctx.GetParams(),
-		}
-	}
+	return this.RowsContext(context.Background())
+}
+
+func (this *selectContext) RowContext(c context.Context) Row {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildSelect(ctx, this.info))
+	return newBuiltRow(this.exe, c, ctx, err)
+}
+
+func (this *selectContext) RowsContext(c context.Context) Rows {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildSelect(ctx, this.info))
+	return newBuiltRows(this.exe, c, ctx, err)
 }
 
 /********** joinType **********/
@@ -197,6 +201,13 @@ func (this *Sorters) AddT(st sortType, t Table, cols ...string) *Sorters {
 	return this
 }
 
+// add sorter with typed expression
+func (this *Sorters) AddX(st sortType, e Expr) *Sorters {
+	s := &sorter{st: st, expr: e}
+	this.sorters = append(this.sorters, s)
+	return this
+}
+
 /********** sortType **********/
 
 type sortType int8
@@ -223,6 +234,7 @@ type sorter struct {
 	st      sortType
 	table   Table
 	columns []string
+	expr    Expr // typed expression used instead of table and columns
 }
 
 // create sorter with table columns
@@ -258,9 +270,19 @@ func (this *Groupers) AddT(t Table, cols ...string) *Groupers {
 	return this
 }
 
+// add grouper with typed expressions
+func (this *Groupers) AddX(exprs ...Expr) *Groupers {
+	grouper := &grouper{
+		exprs: exprs,
+	}
+	this.groupers = append(this.groupers, grouper)
+	return this
+}
+
 /********** grouper **********/
 
 type grouper struct {
 	table   Table
 	columns []string
+	exprs   []Expr
 }
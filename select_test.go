@@ -0,0 +1,142 @@
+package gsd
+
+import (
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u := T("User")
+		s := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID", "NAME").columns})
+		s.From(u).Where(F().Add("STATE", 1)).OrderBy(u.S(SORT_DESC, "ID"))
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `User`.`ID`,`User`.`NAME` FROM `User` WHERE `STATE`=? ORDER BY `User`.`ID` DESC", args: []interface{}{1}},
+		"mssql":     {sql: "SELECT [User].[ID],[User].[NAME] FROM [User] WHERE [STATE]=? ORDER BY [User].[ID] DESC", args: []interface{}{1}},
+		"mssql2005": {sql: "SELECT [User].[ID],[User].[NAME] FROM [User] WHERE [STATE]=? ORDER BY [User].[ID] DESC", args: []interface{}{1}},
+		"sqlite":    {sql: `SELECT "User"."ID","User"."NAME" FROM "User" WHERE "STATE"=? ORDER BY "User"."ID" DESC`, args: []interface{}{1}},
+		"postgres":  {sql: `SELECT "User"."ID","User"."NAME" FROM "User" WHERE "STATE"=$1 ORDER BY "User"."ID" DESC`, args: []interface{}{1}},
+	})
+}
+
+func TestSelectPage(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u := T("User")
+		s := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID", "NAME").columns})
+		s.From(u).Where(F().Add("STATE", 1)).OrderBy(u.S(SORT_DESC, "ID")).Limit(20, 10)
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `User`.`ID`,`User`.`NAME` FROM `User` WHERE `STATE`=? ORDER BY `User`.`ID` DESC LIMIT 20,10", args: []interface{}{1}},
+		"mssql":     {sql: "SELECT [User].[ID],[User].[NAME] FROM [User] WHERE [STATE]=? ORDER BY [User].[ID] DESC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY", args: []interface{}{1}},
+		"mssql2005": {sql: "SELECT [ID],[NAME] FROM (SELECT [User].[ID],[User].[NAME],ROW_NUMBER() OVER(ORDER BY [User].[ID] DESC) AS _N FROM [User] WHERE [STATE]=?) AS _T WHERE _N>20 AND _N<=30", args: []interface{}{1}},
+		"sqlite":    {sql: `SELECT "User"."ID","User"."NAME" FROM "User" WHERE "STATE"=? ORDER BY "User"."ID" DESC LIMIT 10 OFFSET 20`, args: []interface{}{1}},
+		"postgres":  {sql: `SELECT "User"."ID","User"."NAME" FROM "User" WHERE "STATE"=$1 ORDER BY "User"."ID" DESC LIMIT 10 OFFSET 20`, args: []interface{}{1}},
+	})
+}
+
+func TestSelectTake(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u := T("User")
+		s := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID").columns})
+		s.From(u)
+		s.OrderBy(u.S(SORT_ASC, "ID")).Limit(0, 10)
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `User`.`ID` FROM `User` ORDER BY `User`.`ID` ASC LIMIT 0,10"},
+		"mssql":     {sql: "SELECT [User].[ID] FROM [User] ORDER BY [User].[ID] ASC OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY"},
+		"mssql2005": {sql: "SELECT TOP 10 [User].[ID] FROM [User] ORDER BY [User].[ID] ASC"},
+		"sqlite":    {sql: `SELECT "User"."ID" FROM "User" ORDER BY "User"."ID" ASC LIMIT 10 OFFSET 0`},
+		"postgres":  {sql: `SELECT "User"."ID" FROM "User" ORDER BY "User"."ID" ASC LIMIT 10 OFFSET 0`},
+	})
+}
+
+func TestSelectGroup(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		o := T("Order")
+		s := newSelectContext(nil, d, &SelectInfo{columns: o.C("USER_ID").AddX(Sum(Col(o, "AMOUNT")), "TOTAL").columns, distinct: true})
+		s.From(o)
+		s.GroupBy(o.G("USER_ID")).Having(F().AddX(Sum(Col(o, "AMOUNT")), FILTER_GT, 100))
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT DISTINCT `Order`.`USER_ID`,SUM(`Order`.`AMOUNT`) AS `TOTAL` FROM `Order` GROUP BY `Order`.`USER_ID` HAVING SUM(`Order`.`AMOUNT`)>?", args: []interface{}{100}},
+		"mssql":     {sql: "SELECT DISTINCT [Order].[USER_ID],SUM([Order].[AMOUNT]) AS [TOTAL] FROM [Order] GROUP BY [Order].[USER_ID] HAVING SUM([Order].[AMOUNT])>?", args: []interface{}{100}},
+		"mssql2005": {sql: "SELECT DISTINCT [Order].[USER_ID],SUM([Order].[AMOUNT]) AS [TOTAL] FROM [Order] GROUP BY [Order].[USER_ID] HAVING SUM([Order].[AMOUNT])>?", args: []interface{}{100}},
+		"sqlite":    {sql: `SELECT DISTINCT "Order"."USER_ID",SUM("Order"."AMOUNT") AS "TOTAL" FROM "Order" GROUP BY "Order"."USER_ID" HAVING SUM("Order"."AMOUNT")>?`, args: []interface{}{100}},
+		"postgres":  {sql: `SELECT DISTINCT "Order"."USER_ID",SUM("Order"."AMOUNT") AS "TOTAL" FROM "Order" GROUP BY "Order"."USER_ID" HAVING SUM("Order"."AMOUNT")>$1`, args: []interface{}{100}},
+	})
+}
+
+func TestSelectAlias(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u, o := TA("User", "U"), T("Order")
+		q := newSelectContext(nil, d, &SelectInfo{columns: o.C("USER_ID").AddX(Sum(Col(o, "AMOUNT")), "TOTAL").columns})
+		q.From(o)
+		q.GroupBy(o.G("USER_ID"))
+		x := SubT(q, "X")
+		s := newSelectContext(nil, d, &SelectInfo{columns: u.C("NAME").Add(x, "TOTAL").columns})
+		s.From(u).Join(x, F().AddJ(u, "ID", FILTER_EQ, x, "USER_ID")).Where(F().AddF(u, "ID", FILTER_EQ, 1))
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `U`.`NAME`,`X`.`TOTAL` FROM `User` AS `U` JOIN (SELECT `Order`.`USER_ID`,SUM(`Order`.`AMOUNT`) AS `TOTAL` FROM `Order` GROUP BY `Order`.`USER_ID`) AS `X` ON `U`.`ID`=`X`.`USER_ID` WHERE `U`.`ID`=?", args: []interface{}{1}},
+		"mssql":     {sql: "SELECT [U].[NAME],[X].[TOTAL] FROM [User] AS [U] JOIN (SELECT [Order].[USER_ID],SUM([Order].[AMOUNT]) AS [TOTAL] FROM [Order] GROUP BY [Order].[USER_ID]) AS [X] ON [U].[ID]=[X].[USER_ID] WHERE [U].[ID]=?", args: []interface{}{1}},
+		"mssql2005": {sql: "SELECT [U].[NAME],[X].[TOTAL] FROM [User] AS [U] JOIN (SELECT [Order].[USER_ID],SUM([Order].[AMOUNT]) AS [TOTAL] FROM [Order] GROUP BY [Order].[USER_ID]) AS [X] ON [U].[ID]=[X].[USER_ID] WHERE [U].[ID]=?", args: []interface{}{1}},
+		"sqlite":    {sql: `SELECT "U"."NAME","X"."TOTAL" FROM "User" AS "U" JOIN (SELECT "Order"."USER_ID",SUM("Order"."AMOUNT") AS "TOTAL" FROM "Order" GROUP BY "Order"."USER_ID") AS "X" ON "U"."ID"="X"."USER_ID" WHERE "U"."ID"=?`, args: []interface{}{1}},
+		"postgres":  {sql: `SELECT "U"."NAME","X"."TOTAL" FROM "User" AS "U" JOIN (SELECT "Order"."USER_ID",SUM("Order"."AMOUNT") AS "TOTAL" FROM "Order" GROUP BY "Order"."USER_ID") AS "X" ON "U"."ID"="X"."USER_ID" WHERE "U"."ID"=$1`, args: []interface{}{1}},
+	})
+}
+
+func TestSelectSkip(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u := T("User")
+		s := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID").columns})
+		s.From(u)
+		s.OrderBy(u.S(SORT_ASC, "ID")).Limit(20, 0)
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT `User`.`ID` FROM `User` ORDER BY `User`.`ID` ASC LIMIT 20,18446744073709551615"},
+		"mssql":     {sql: "SELECT [User].[ID] FROM [User] ORDER BY [User].[ID] ASC OFFSET 20 ROWS"},
+		"mssql2005": {sql: "SELECT [ID] FROM (SELECT [User].[ID],ROW_NUMBER() OVER(ORDER BY [User].[ID] ASC) AS _N FROM [User]) AS _T WHERE _N>20"},
+		"sqlite":    {sql: `SELECT "User"."ID" FROM "User" ORDER BY "User"."ID" ASC LIMIT -1 OFFSET 20`},
+		"postgres":  {sql: `SELECT "User"."ID" FROM "User" ORDER BY "User"."ID" ASC OFFSET 20`},
+	})
+}
+
+func TestSelectFromCompound(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		a, b := T("A"), T("B")
+		q1 := newSelectContext(nil, d, &SelectInfo{columns: a.C("ID").columns})
+		q1.From(a).Where(F().Add("X", 1))
+		q2 := newSelectContext(nil, d, &SelectInfo{columns: b.C("ID").columns})
+		q2.From(b)
+		cc := newCompoundContext(nil, d, &CompoundInfo{queries: []interface{}{q1}})
+		cc.UnionAll(q2)
+		x := SubT(cc, "X")
+		s := newSelectContext(nil, d, &SelectInfo{columns: C(false).AddX(CountAll(), "TOTAL").columns})
+		s.From(x).Where(F().AddF(x, "ID", FILTER_GT, 10))
+		return s
+	}, map[string]golden{
+		"mysql":     {sql: "SELECT COUNT(*) AS `TOTAL` FROM ((SELECT `A`.`ID` FROM `A` WHERE `X`=?) UNION ALL (SELECT `B`.`ID` FROM `B`)) AS `X` WHERE `X`.`ID`>?", args: []interface{}{1, 10}},
+		"mssql":     {sql: `SELECT COUNT(*) AS [TOTAL] FROM ((SELECT [A].[ID] FROM [A] WHERE [X]=?) UNION ALL (SELECT [B].[ID] FROM [B])) AS [X] WHERE [X].[ID]>?`, args: []interface{}{1, 10}},
+		"mssql2005": {sql: `SELECT COUNT(*) AS [TOTAL] FROM ((SELECT [A].[ID] FROM [A] WHERE [X]=?) UNION ALL (SELECT [B].[ID] FROM [B])) AS [X] WHERE [X].[ID]>?`, args: []interface{}{1, 10}},
+		"sqlite":    {sql: `SELECT COUNT(*) AS "TOTAL" FROM (SELECT "A"."ID" FROM "A" WHERE "X"=? UNION ALL SELECT "B"."ID" FROM "B") AS "X" WHERE "X"."ID">?`, args: []interface{}{1, 10}},
+		"postgres":  {sql: `SELECT COUNT(*) AS "TOTAL" FROM ((SELECT "A"."ID" FROM "A" WHERE "X"=$1) UNION ALL (SELECT "B"."ID" FROM "B")) AS "X" WHERE "X"."ID">$2`, args: []interface{}{1, 10}},
+	})
+}
+
+func TestSelectDerivedNoAlias(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		o := T("Order")
+		q := newSelectContext(nil, d, &SelectInfo{columns: o.C("USER_ID").columns})
+		q.From(o)
+		x := SubT(q, "")
+		s := newSelectContext(nil, d, &SelectInfo{columns: x.C("USER_ID").columns})
+		s.From(x)
+		return s
+	}, map[string]golden{
+		"mysql":     {err: true},
+		"mssql":     {err: true},
+		"mssql2005": {err: true},
+		"sqlite":    {err: true},
+		"postgres":  {err: true},
+	})
+}
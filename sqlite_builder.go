@@ -0,0 +1,71 @@
+package gsd
+
+import (
+	"fmt"
+)
+
+/********** sqliteBuilder **********/
+
+type sqliteBuilder struct {
+	*Builder
+}
+
+func newSqliteBuilder() *sqliteBuilder {
+	b := &sqliteBuilder{}
+	b.Builder = NewBuilder(b)
+	return b
+}
+
+// BuildCompound build query string and parameters for compound select action,
+// SQLite doesn't allow parenthesized queries in compound select, so queries can't have ORDER BY or LIMIT.
+func (this *sqliteBuilder) BuildCompound(ctx *BuildContext, info *CompoundInfo) error {
+	if err := this.buildCompoundQueries(ctx, info, false); err != nil {
+		return err
+	}
+	this.buildOrders(ctx, info.orders)
+
+	// LIMIT
+	if info.skip != 0 || info.take != 0 {
+		ctx.AppendSql(this.Page(info.skip, info.take))
+	}
+
+	return nil
+}
+
+// BuildDelete build query string and parameters for delete action, SQLite doesn't support joins in DELETE,
+// so rows are chosen by rowid with a select action, like: DELETE FROM a WHERE rowid IN(SELECT a.rowid FROM a JOIN b ON ...)
+func (this *sqliteBuilder) BuildDelete(ctx *BuildContext, info *DeleteInfo) error {
+	if len(info.joins) == 0 {
+		return this.Builder.BuildDelete(ctx, info)
+	}
+
+	table := this.Quote(info.table)
+	ctx.AppendSql("DELETE FROM ", table, " WHERE rowid IN(SELECT ", table, ".rowid FROM ", table)
+	if err := this.buildJoins(ctx, info.joins); err != nil {
+		return err
+	}
+	if err := this.buildWhere(ctx, info.where); err != nil {
+		return err
+	}
+	ctx.AppendSql(")")
+	this.buildReturning(ctx, info.table, info.returning)
+	return nil
+}
+
+// Quote returns quoted identifier, like "ID"
+func (this *sqliteBuilder) Quote(name string) string {
+	return `"` + name + `"`
+}
+
+// Page returns LIMIT/OFFSET clause, LIMIT -1 means no limit if take is 0
+func (this *sqliteBuilder) Page(skip, take int32) string {
+	if take == 0 {
+		return fmt.Sprintf(" LIMIT -1 OFFSET %d", skip)
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", take, skip)
+}
+
+// MaxParams returns maximum count of parameters in a statement
+func (this *sqliteBuilder) MaxParams() int {
+	return 999
+}
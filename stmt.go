@@ -0,0 +1,206 @@
+package gsd
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+/********** stmtCache **********/
+
+// stmtCache is an executor which runs queries with prepared statements of db, statements are cached by query string,
+// the least recently used one is closed when count of them exceeds size.
+type stmtCache struct {
+	db     *sql.DB
+	size   int
+	locker sync.Mutex
+	items  map[string]*list.Element
+	lru    *list.List // front is the most recently used
+	closed bool
+}
+
+// stmtEntry is a cached statement, it is closed after eviction only when no one is using it
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(db *sql.DB, size int) *stmtCache {
+	return &stmtCache{
+		db:    db,
+		size:  size,
+		items: make(map[string]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// acquire returns cached statement of query, or prepares a new one, the entry must be released after using
+func (this *stmtCache) acquire(c context.Context, query string) (*stmtEntry, error) {
+	this.locker.Lock()
+	if e, ok := this.items[query]; ok {
+		this.lru.MoveToFront(e)
+		entry := e.Value.(*stmtEntry)
+		entry.refs++
+		this.locker.Unlock()
+		return entry, nil
+	}
+	this.locker.Unlock()
+
+	// prepare without lock, so other queries are not blocked
+	stmt, err := this.db.PrepareContext(c, query)
+	if err != nil {
+		return nil, err
+	}
+
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	if e, ok := this.items[query]; ok {
+		// prepared by another goroutine at the same time
+		stmt.Close()
+		this.lru.MoveToFront(e)
+		entry := e.Value.(*stmtEntry)
+		entry.refs++
+		return entry, nil
+	}
+
+	entry := &stmtEntry{query: query, stmt: stmt, refs: 1}
+	if this.closed {
+		// don't cache statements after the pool is closed, it is closed once released
+		entry.evicted = true
+		return entry, nil
+	}
+
+	this.items[query] = this.lru.PushFront(entry)
+	for this.lru.Len() > this.size {
+		this.evict(this.lru.Back())
+	}
+	return entry, nil
+}
+
+// release decreases reference count of entry, and closes it if it was evicted
+func (this *stmtCache) release(entry *stmtEntry) {
+	this.locker.Lock()
+	entry.refs--
+	closing := entry.evicted && entry.refs == 0
+	this.locker.Unlock()
+
+	if closing {
+		entry.stmt.Close()
+	}
+}
+
+// evict removes e from cache, caller must hold the locker
+func (this *stmtCache) evict(e *list.Element) {
+	entry := this.lru.Remove(e).(*stmtEntry)
+	delete(this.items, entry.query)
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// close evicts all statements, statements in use are closed once released
+func (this *stmtCache) close() {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	this.closed = true
+	for this.lru.Len() > 0 {
+		this.evict(this.lru.Back())
+	}
+}
+
+func (this *stmtCache) ExecContext(c context.Context, query string, args ...interface{}) (sql.Result, error) {
+	entry, err := this.acquire(c, query)
+	if err != nil {
+		return this.db.ExecContext(c, query, args...)
+	}
+	defer this.release(entry)
+	return entry.stmt.ExecContext(c, args...)
+}
+
+func (this *stmtCache) QueryContext(c context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	entry, err := this.acquire(c, query)
+	if err != nil {
+		return this.db.QueryContext(c, query, args...)
+	}
+	defer this.release(entry)
+	return entry.stmt.QueryContext(c, args...)
+}
+
+func (this *stmtCache) QueryRowContext(c context.Context, query string, args ...interface{}) *sql.Row {
+	entry, err := this.acquire(c, query)
+	if err != nil {
+		// the error is reported by sql.Row
+		return this.db.QueryRowContext(c, query, args...)
+	}
+	defer this.release(entry)
+	return entry.stmt.QueryRowContext(c, args...)
+}
+
+/********** txStmts **********/
+
+// txStmts is an executor which runs queries of a transaction with statements from cache,
+// statements of the transaction are closed by database/sql when it is committed or rolled back.
+type txStmts struct {
+	tx     *sql.Tx
+	cache  *stmtCache
+	locker sync.Mutex
+	stmts  map[string]*sql.Stmt
+}
+
+func newTxStmts(tx *sql.Tx, cache *stmtCache) *txStmts {
+	return &txStmts{
+		tx:    tx,
+		cache: cache,
+		stmts: make(map[string]*sql.Stmt),
+	}
+}
+
+// stmt returns transaction-specific statement of query
+func (this *txStmts) stmt(c context.Context, query string) (*sql.Stmt, error) {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	if stmt, ok := this.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	entry, err := this.cache.acquire(c, query)
+	if err != nil {
+		return nil, err
+	}
+	defer this.cache.release(entry)
+
+	stmt := this.tx.StmtContext(c, entry.stmt)
+	this.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (this *txStmts) ExecContext(c context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := this.stmt(c, query)
+	if err != nil {
+		return this.tx.ExecContext(c, query, args...)
+	}
+	return stmt.ExecContext(c, args...)
+}
+
+func (this *txStmts) QueryContext(c context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := this.stmt(c, query)
+	if err != nil {
+		return this.tx.QueryContext(c, query, args...)
+	}
+	return stmt.QueryContext(c, args...)
+}
+
+func (this *txStmts) QueryRowContext(c context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := this.stmt(c, query)
+	if err != nil {
+		return this.tx.QueryRowContext(c, query, args...)
+	}
+	return stmt.QueryRowContext(c, args...)
+}
@@ -0,0 +1,173 @@
+package gsd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func openTestDB(t *testing.T, dsn string) (*sql.DB, *testServer) {
+	s := newTestServer(t, dsn)
+	db, err := sql.Open("gsdtest", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, s
+}
+
+func TestStmtCacheReuse(t *testing.T) {
+	db, s := openTestDB(t, "stmt-reuse")
+	cache := newStmtCache(db, 2)
+	defer cache.close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.ExecContext(context.Background(), "UPDATE A SET X=?", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := s.Count(func() int { return s.prepares }); n != 1 {
+		t.Errorf("statement should be prepared once, got %d", n)
+	}
+	if n := len(s.Queries()); n != 3 {
+		t.Errorf("expect 3 queries, got %d", n)
+	}
+}
+
+func TestStmtCacheEvictInUse(t *testing.T) {
+	db, s := openTestDB(t, "stmt-evict")
+	cache := newStmtCache(db, 1)
+	defer cache.close()
+
+	c := context.Background()
+	e1, err := cache.acquire(c, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// e1 is evicted by e2, but it is still in use
+	e2, err := cache.acquire(c, "SELECT 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.release(e2)
+
+	if !e1.evicted {
+		t.Error("the least recently used statement should be evicted")
+	}
+	if n := s.Count(func() int { return s.stmts }); n != 2 {
+		t.Fatalf("statement in use should not be closed, got %d open statements", n)
+	}
+	if _, err = e1.stmt.ExecContext(c); err != nil {
+		t.Fatalf("evicted statement in use should still work: %v", err)
+	}
+
+	cache.release(e1)
+	if n := s.Count(func() int { return s.stmts }); n != 1 {
+		t.Errorf("evicted statement should be closed after release, got %d open statements", n)
+	}
+}
+
+func TestStmtCacheClose(t *testing.T) {
+	db, s := openTestDB(t, "stmt-close")
+	cache := newStmtCache(db, 2)
+
+	c := context.Background()
+	e1, err := cache.acquire(c, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cache.ExecContext(c, "SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.close()
+	if n := s.Count(func() int { return s.stmts }); n != 1 {
+		t.Fatalf("only the statement in use should be left open, got %d open statements", n)
+	}
+	cache.release(e1)
+	if n := s.Count(func() int { return s.stmts }); n != 0 {
+		t.Fatalf("statement should be closed after release, got %d open statements", n)
+	}
+
+	// statements are not cached any more after closing
+	if _, err = cache.ExecContext(c, "SELECT 3"); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Count(func() int { return s.stmts }); n != 0 {
+		t.Errorf("statement should not be cached after closing, got %d open statements", n)
+	}
+}
+
+func TestTxStmts(t *testing.T) {
+	db, s := openTestDB(t, "stmt-tx")
+	cache := newStmtCache(db, 2)
+
+	c := context.Background()
+	transact := func() {
+		tx, err := db.BeginTx(c, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+
+		exe := newTxStmts(tx, cache)
+		for i := 0; i < 3; i++ {
+			if _, err = exe.ExecContext(c, "UPDATE A SET X=?", i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(exe.stmts) != 1 {
+			t.Errorf("statement of the transaction should be reused, got %d", len(exe.stmts))
+		}
+		if err = tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the cached statement is prepared on another connection since the transaction holds one,
+	// database/sql prepares it again on connection of the transaction and keeps it with the cached one.
+	transact()
+	prepares := s.Count(func() int { return s.prepares })
+	transact()
+	if n := s.Count(func() int { return s.prepares }); n != prepares {
+		t.Errorf("statements should be reused by later transactions, got %d prepares, want %d", n, prepares)
+	}
+	if n := len(s.Queries()); n != 6 {
+		t.Errorf("expect 6 queries, got %d", n)
+	}
+
+	cache.close()
+	if n := s.Count(func() int { return s.stmts }); n != 0 {
+		t.Errorf("statements of transactions should be closed with the cache, got %d open statements", n)
+	}
+}
+
+func TestStmtCacheConcurrent(t *testing.T) {
+	db, s := openTestDB(t, "stmt-concurrent")
+	cache := newStmtCache(db, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				query := fmt.Sprintf("SELECT %d", (i+j)%5)
+				rows, err := cache.QueryContext(context.Background(), query)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				rows.Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cache.close()
+	if n := s.Count(func() int { return s.stmts }); n != 0 {
+		t.Errorf("all statements should be closed, got %d open statements", n)
+	}
+}
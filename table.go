@@ -54,3 +54,43 @@ func (this *basicTable) G(cols ...string) *Groupers {
 func (this *basicTable) S(st sortType, cols ...string) *Sorters {
 	return new(Sorters).AddT(st, this, cols...)
 }
+
+// SubT returns a derived table of select clause q, columns of it are referenced by alias which must not be empty, like:
+//
+//	x := gsd.SubT(db.Select(...).From(t).GroupBy(g), "x")
+//	db.Select(x.C("USER_ID", "TOTAL")).From(x).Where(f)
+func SubT(q interface{}, alias string) Table {
+	return &subTable{
+		query: q,
+		alias: alias,
+	}
+}
+
+type subTable struct {
+	query interface{}
+	alias string
+}
+
+func (this *subTable) Name() string {
+	return this.alias
+}
+
+func (this *subTable) Alias() string {
+	return this.alias
+}
+
+func (this *subTable) Prefix() string {
+	return this.alias
+}
+
+func (this *subTable) C(cols ...string) *Columns {
+	return new(Columns).Add(this, cols...)
+}
+
+func (this *subTable) G(cols ...string) *Groupers {
+	return new(Groupers).AddT(this, cols...)
+}
+
+func (this *subTable) S(st sortType, cols ...string) *Sorters {
+	return new(Sorters).AddT(st, this, cols...)
+}
@@ -4,44 +4,73 @@ import (
 	"database/sql"
 )
 
-type Transaction interface {
+// Session is the common interface of Database, Transaction and Database.Primary().
+type Session interface {
 	Insert(table string) InsertClause
 	Delete(table string) DeleteClause
 	Update(table string) UpdateClause
+	Upsert(table string) UpsertClause
 	Select(columns *Columns) SelectClause
+	Compound(q interface{}) CompoundClause
+	With(name string, q interface{}) WithClause
+	WithRecursive(name string, q interface{}) WithClause
 	Execute(query string, args ...interface{}) ExecuteClause
 }
 
+type Transaction interface {
+	Session
+}
+
 type transaction struct {
-	tx *sql.Tx
-	b  builder
+	tx    *sql.Tx
+	exe   executor // tx itself, or cached statements of tx
+	b     Dialect
+	batch int
 }
 
-func newTransaction(tx *sql.Tx, b builder) *transaction {
+func newTransaction(tx *sql.Tx, exe executor, b Dialect, batch int) *transaction {
 	return &transaction{
-		tx: tx,
-		b:  b,
+		tx:    tx,
+		exe:   exe,
+		b:     b,
+		batch: batch,
 	}
 }
 
 func (this *transaction) Insert(table string) InsertClause {
-	return newInsertContext(this.tx, this.b, &insertInfo{table: table})
+	return newInsertContext(this.exe, this.b, &InsertInfo{table: table, batch: this.batch})
 }
 
 func (this *transaction) Delete(table string) DeleteClause {
-	return newDeleteContext(this.tx, this.b, &deleteInfo{table: table})
+	return newDeleteContext(this.exe, this.b, &DeleteInfo{table: table})
 }
 
 func (this *transaction) Update(table string) UpdateClause {
-	return newUpdateContext(this.tx, this.b, &updateInfo{table: table})
+	return newUpdateContext(this.exe, this.b, &UpdateInfo{table: table})
+}
+
+func (this *transaction) Upsert(table string) UpsertClause {
+	return newUpsertContext(this.exe, this.b, &UpsertInfo{table: table})
 }
 
 func (this *transaction) Select(columns *Columns) SelectClause {
-	return newSelectContext(this.tx, this.b, &selectInfo{columns: columns.columns})
+	return newSelectContext(this.exe, this.b, &SelectInfo{columns: columns.columns})
+}
+
+func (this *transaction) Compound(q interface{}) CompoundClause {
+	return newCompoundContext(this.exe, this.b, &CompoundInfo{queries: []interface{}{q}})
+}
+
+func (this *transaction) With(name string, q interface{}) WithClause {
+	return newWithContext(this.exe, this.b).With(name, q)
+}
+
+func (this *transaction) WithRecursive(name string, q interface{}) WithClause {
+	return newWithContext(this.exe, this.b).WithRecursive(name, q)
 }
 
 func (this *transaction) Execute(query string, args ...interface{}) ExecuteClause {
-	return newExecuteContext(this.tx, query, args)
+	return newExecuteContext(this.exe, this.exe, query, args)
 }
 
 func (this *transaction) Commit() error {
@@ -1,22 +1,29 @@
 package gsd
 
-/********** updateInfo **********/
+import (
+	"context"
+	"sort"
+)
+
+/********** UpdateInfo **********/
 
-type updateInfo struct {
-	table  string
-	values map[string]*updateValue
-	where  Filters
+type UpdateInfo struct {
+	table     string
+	joins     []*joiner
+	values    map[string]*updateValue
+	where     Filters
+	returning []string
 }
 
 /********** updateContext **********/
 
 type updateContext struct {
 	exe  executor
-	b    builder
-	info *updateInfo
+	b    Dialect
+	info *UpdateInfo
 }
 
-func newUpdateContext(exe executor, b builder, info *updateInfo) *updateContext {
+func newUpdateContext(exe executor, b Dialect, info *UpdateInfo) *updateContext {
 	return &updateContext{
 		exe:  exe,
 		b:    b,
@@ -24,23 +31,67 @@ func newUpdateContext(exe executor, b builder, info *updateInfo) *updateContext
 	}
 }
 
+// Join joins table t, columns of t can be referenced by update values and filters, like:
+//
+//	db.Update("User").Join(o, gsd.F().AddJ(u, "ID", gsd.FILTER_EQ, o, "USER_ID")).Set(gsd.UpdateValues{"AMOUNT": gsd.UV(gsd.Col(o, "AMOUNT"))})
+func (this *updateContext) Join(t Table, on Filters) UpdateClause {
+	this.info.joins = append(this.info.joins, &joiner{jt: JOIN_INNER, t: t, on: on})
+	return this
+}
+
+// LeftJoin joins table t with LEFT JOIN, it is not supported by PostgreSQL and SQLite
+func (this *updateContext) LeftJoin(t Table, on Filters) UpdateClause {
+	this.info.joins = append(this.info.joins, &joiner{jt: JOIN_LEFT, t: t, on: on})
+	return this
+}
+
 func (this *updateContext) Set(values UpdateValues) SetClause {
 	this.info.values = values
 	return this
 }
 
-func (this *updateContext) Where(f Filters) ResultClause {
+func (this *updateContext) Where(f Filters) ReturningClause {
 	this.info.where = f
 	return this
 }
 
 func (this *updateContext) Result() (Result, error) {
-	ctx := newBuildContext()
-	err := this.b.BuildUpdate(ctx, this.info)
+	return this.ResultContext(context.Background())
+}
+
+func (this *updateContext) ResultContext(c context.Context) (Result, error) {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildUpdate(ctx, this.info))
 	if err != nil {
 		return nil, err
 	}
-	return this.exe.Exec(ctx.GetSql(), ctx.GetParams()...)
+	return this.exe.ExecContext(c, ctx.GetSql(), ctx.GetParams()...)
+}
+
+// Returning returns columns of updated rows with new values instead of the result
+func (this *updateContext) Returning(cols ...string) RowClause {
+	this.info.returning = cols
+	return this
+}
+
+func (this *updateContext) Row() Row {
+	return this.RowContext(context.Background())
+}
+
+func (this *updateContext) Rows() Rows {
+	return this.RowsContext(context.Background())
+}
+
+func (this *updateContext) RowContext(c context.Context) Row {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildUpdate(ctx, this.info))
+	return newBuiltRow(this.exe, c, ctx, err)
+}
+
+func (this *updateContext) RowsContext(c context.Context) Rows {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildUpdate(ctx, this.info))
+	return newBuiltRows(this.exe, c, ctx, err)
 }
 
 /********** UpdateType **********/
@@ -86,3 +137,13 @@ func (this UpdateValues) Remove(name string) UpdateValues {
 	delete(this, name)
 	return this
 }
+
+// Keys returns sorted column names, so generated SQL is the same for the same columns
+func (this UpdateValues) Keys() []string {
+	keys := make([]string, 0, len(this))
+	for k := range this {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,34 @@
+package gsd
+
+import (
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		values := UpdateValues{"NAME": UV("a"), "COUNT": UVT(UPDATE_INC, 1)}
+		return newUpdateContext(nil, d, &UpdateInfo{table: "User"}).Set(values).Where(F().Add("ID", 1))
+	}, map[string]golden{
+		"mysql":     {sql: "UPDATE `User` SET `COUNT`=`COUNT`+?, `NAME`=? WHERE `ID`=?", args: []interface{}{1, "a", 1}},
+		"mssql":     {sql: "UPDATE [User] SET [COUNT]=[COUNT]+?, [NAME]=? WHERE [ID]=?", args: []interface{}{1, "a", 1}},
+		"mssql2005": {sql: "UPDATE [User] SET [COUNT]=[COUNT]+?, [NAME]=? WHERE [ID]=?", args: []interface{}{1, "a", 1}},
+		"sqlite":    {sql: `UPDATE "User" SET "COUNT"="COUNT"+?, "NAME"=? WHERE "ID"=?`, args: []interface{}{1, "a", 1}},
+		"postgres":  {sql: `UPDATE "User" SET "COUNT"="COUNT"+$1, "NAME"=$2 WHERE "ID"=$3`, args: []interface{}{1, "a", 1}},
+	})
+}
+
+func TestUpdateJoin(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		u, o := T("User"), T("Order")
+		return newUpdateContext(nil, d, &UpdateInfo{table: "User"}).
+			Join(o, F().AddJ(u, "ID", FILTER_EQ, o, "USER_ID")).
+			Set(UpdateValues{"STATE": UV(1)}).
+			Where(F().AddF(o, "AMOUNT", FILTER_GT, 100))
+	}, map[string]golden{
+		"mysql":     {sql: "UPDATE `User` JOIN `Order` ON `User`.`ID`=`Order`.`USER_ID` SET `User`.`STATE`=? WHERE `Order`.`AMOUNT`>?", args: []interface{}{1, 100}},
+		"mssql":     {sql: "UPDATE [User] SET [STATE]=? FROM [User] JOIN [Order] ON [User].[ID]=[Order].[USER_ID] WHERE [Order].[AMOUNT]>?", args: []interface{}{1, 100}},
+		"mssql2005": {sql: "UPDATE [User] SET [STATE]=? FROM [User] JOIN [Order] ON [User].[ID]=[Order].[USER_ID] WHERE [Order].[AMOUNT]>?", args: []interface{}{1, 100}},
+		"sqlite":    {sql: `UPDATE "User" SET "STATE"=? FROM "Order" WHERE ("User"."ID"="Order"."USER_ID") AND ("Order"."AMOUNT">?)`, args: []interface{}{1, 100}},
+		"postgres":  {sql: `UPDATE "User" SET "STATE"=$1 FROM "Order" WHERE ("User"."ID"="Order"."USER_ID") AND ("Order"."AMOUNT">$2)`, args: []interface{}{1, 100}},
+	})
+}
@@ -0,0 +1,60 @@
+package gsd
+
+import (
+	"context"
+)
+
+/********** UpsertInfo **********/
+
+type UpsertInfo struct {
+	table   string
+	values  map[string]interface{}
+	keys    []string
+	updates map[string]*updateValue
+}
+
+/********** upsertContext **********/
+
+type upsertContext struct {
+	exe  executor
+	b    Dialect
+	info *UpsertInfo
+}
+
+func newUpsertContext(exe executor, b Dialect, info *UpsertInfo) *upsertContext {
+	return &upsertContext{
+		exe:  exe,
+		b:    b,
+		info: info,
+	}
+}
+
+func (this *upsertContext) Values(values InsertValues) UpsertValuesClause {
+	this.info.values = values
+	return this
+}
+
+// Keys sets columns to detect conflicting rows, MySQL ignores them and uses unique indexes of the table
+func (this *upsertContext) Keys(cols ...string) UpsertKeysClause {
+	this.info.keys = cols
+	return this
+}
+
+// Update sets values for updating when row already exists, UPDATE_INC increases the existing value
+func (this *upsertContext) Update(values UpdateValues) ResultClause {
+	this.info.updates = values
+	return this
+}
+
+func (this *upsertContext) Result() (Result, error) {
+	return this.ResultContext(context.Background())
+}
+
+func (this *upsertContext) ResultContext(c context.Context) (Result, error) {
+	ctx := newBuildContext(this.b)
+	err := ctx.check(this.b.BuildUpsert(ctx, this.info))
+	if err != nil {
+		return nil, err
+	}
+	return this.exe.ExecContext(c, ctx.GetSql(), ctx.GetParams()...)
+}
@@ -0,0 +1,45 @@
+package gsd
+
+import (
+	"testing"
+)
+
+func upsertUser(keys ...string) func(d Dialect) interface{} {
+	return func(d Dialect) interface{} {
+		return newUpsertContext(nil, d, &UpsertInfo{table: "User"}).
+			Values(InsertValues{"ID": 1, "NAME": "a"}).
+			Keys(keys...).
+			Update(UpdateValues{"NAME": UV("a"), "COUNT": UVT(UPDATE_INC, 1)})
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	testBuild(t, upsertUser("ID"), map[string]golden{
+		"mysql":     {sql: "INSERT INTO `User`(`ID`,`NAME`) VALUES(?,?) ON DUPLICATE KEY UPDATE `COUNT`=`COUNT`+?, `NAME`=?", args: []interface{}{1, "a", 1, "a"}},
+		"mssql":     {sql: `MERGE INTO [User] WITH (HOLDLOCK) AS _T USING (SELECT ? AS [ID],? AS [NAME]) AS _S ON _T.[ID]=_S.[ID] WHEN MATCHED THEN UPDATE SET [COUNT]=_T.[COUNT]+?, [NAME]=? WHEN NOT MATCHED THEN INSERT([ID],[NAME]) VALUES(_S.[ID],_S.[NAME]);`, args: []interface{}{1, "a", 1, "a"}},
+		"mssql2005": {sql: `UPDATE [User] WITH (UPDLOCK, HOLDLOCK) SET [COUNT]=[COUNT]+?, [NAME]=? WHERE [ID]=?; IF @@ROWCOUNT=0 INSERT INTO [User]([ID],[NAME]) VALUES(?,?);`, args: []interface{}{1, "a", 1, 1, "a"}},
+		"sqlite":    {sql: `INSERT INTO "User"("ID","NAME") VALUES(?,?) ON CONFLICT("ID") DO UPDATE SET "COUNT"="User"."COUNT"+?, "NAME"=?`, args: []interface{}{1, "a", 1, "a"}},
+		"postgres":  {sql: `INSERT INTO "User"("ID","NAME") VALUES($1,$2) ON CONFLICT("ID") DO UPDATE SET "COUNT"="User"."COUNT"+$3, "NAME"=$4`, args: []interface{}{1, "a", 1, "a"}},
+	})
+}
+
+func TestUpsertNoKeys(t *testing.T) {
+	// MySQL detects conflicts by unique indexes of the table
+	testBuild(t, upsertUser(), map[string]golden{
+		"mysql":     {sql: "INSERT INTO `User`(`ID`,`NAME`) VALUES(?,?) ON DUPLICATE KEY UPDATE `COUNT`=`COUNT`+?, `NAME`=?", args: []interface{}{1, "a", 1, "a"}},
+		"mssql":     {err: true},
+		"mssql2005": {err: true},
+		"sqlite":    {err: true},
+		"postgres":  {err: true},
+	})
+}
+
+func TestUpsertMissingKey(t *testing.T) {
+	// SQL Server matches rows by values of keys
+	for _, p := range []string{"mssql", "mssql2005"} {
+		d, _ := getDialect(p)
+		if _, _, err := Debug(upsertUser("CODE")(d)); err == nil {
+			t.Errorf("%s: expect error of missing key", p)
+		}
+	}
+}
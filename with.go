@@ -0,0 +1,43 @@
+package gsd
+
+/********** cte **********/
+
+// cte is a common table expression, it can be used as a table by its name, like: gsd.T(name)
+type cte struct {
+	name      string
+	query     interface{} // select or compound clause
+	recursive bool
+}
+
+/********** withContext **********/
+
+type withContext struct {
+	exe  executor
+	b    Dialect
+	ctes []*cte
+}
+
+func newWithContext(exe executor, b Dialect) *withContext {
+	return &withContext{
+		exe: exe,
+		b:   b,
+	}
+}
+
+func (this *withContext) With(name string, q interface{}) WithClause {
+	this.ctes = append(this.ctes, &cte{name: name, query: q})
+	return this
+}
+
+// WithRecursive adds a recursive cte, q is normally a compound clause which references the cte itself, like:
+//
+//	t := gsd.T("tree")
+//	q := db.Compound(anchor).UnionAll(db.Select(c.C("ID", "PARENT_ID")).From(c).Join(t, gsd.F().AddJ(c, "PARENT_ID", gsd.FILTER_EQ, t, "ID")))
+func (this *withContext) WithRecursive(name string, q interface{}) WithClause {
+	this.ctes = append(this.ctes, &cte{name: name, query: q, recursive: true})
+	return this
+}
+
+func (this *withContext) Select(columns *Columns) SelectClause {
+	return newSelectContext(this.exe, this.b, &SelectInfo{ctes: this.ctes, columns: columns.columns, distinct: columns.distinct})
+}
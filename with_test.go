@@ -0,0 +1,81 @@
+package gsd
+
+import (
+	"testing"
+)
+
+// withTop returns a select with cte "top", which contains orders with AMOUNT>100
+func withTop(d Dialect) *selectContext {
+	o, top := T("Order"), T("top")
+	q := newSelectContext(nil, d, &SelectInfo{columns: o.C("USER_ID", "AMOUNT").columns})
+	q.From(o).Where(F().AddT("AMOUNT", FILTER_GT, 100))
+	sc := newWithContext(nil, d).With("top", q).Select(top.C("USER_ID")).(*selectContext)
+	sc.From(top)
+	return sc
+}
+
+func TestWith(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		sc := withTop(d)
+		sc.Where(F().Add("USER_ID", 1))
+		return sc
+	}, map[string]golden{
+		"mysql":     {sql: "WITH `top` AS (SELECT `Order`.`USER_ID`,`Order`.`AMOUNT` FROM `Order` WHERE `AMOUNT`>?) SELECT `top`.`USER_ID` FROM `top` WHERE `USER_ID`=?", args: []interface{}{100, 1}},
+		"mssql":     {sql: `WITH [top] AS (SELECT [Order].[USER_ID],[Order].[AMOUNT] FROM [Order] WHERE [AMOUNT]>?) SELECT [top].[USER_ID] FROM [top] WHERE [USER_ID]=?`, args: []interface{}{100, 1}},
+		"mssql2005": {sql: `WITH [top] AS (SELECT [Order].[USER_ID],[Order].[AMOUNT] FROM [Order] WHERE [AMOUNT]>?) SELECT [top].[USER_ID] FROM [top] WHERE [USER_ID]=?`, args: []interface{}{100, 1}},
+		"sqlite":    {sql: `WITH "top" AS (SELECT "Order"."USER_ID","Order"."AMOUNT" FROM "Order" WHERE "AMOUNT">?) SELECT "top"."USER_ID" FROM "top" WHERE "USER_ID"=?`, args: []interface{}{100, 1}},
+		"postgres":  {sql: `WITH "top" AS (SELECT "Order"."USER_ID","Order"."AMOUNT" FROM "Order" WHERE "AMOUNT">$1) SELECT "top"."USER_ID" FROM "top" WHERE "USER_ID"=$2`, args: []interface{}{100, 1}},
+	})
+}
+
+func TestWithRecursive(t *testing.T) {
+	testBuild(t, func(d Dialect) interface{} {
+		c, tree := T("Category"), T("tree")
+		anchor := newSelectContext(nil, d, &SelectInfo{columns: c.C("ID", "PARENT_ID").columns})
+		anchor.From(c).Where(F().Add("ID", 1))
+		children := newSelectContext(nil, d, &SelectInfo{columns: c.C("ID", "PARENT_ID").columns})
+		children.From(c).Join(tree, F().AddJ(c, "PARENT_ID", FILTER_EQ, tree, "ID"))
+		cc := newCompoundContext(nil, d, &CompoundInfo{queries: []interface{}{anchor}})
+		cc.UnionAll(children)
+		return newWithContext(nil, d).WithRecursive("tree", cc).Select(tree.C("ID")).From(tree)
+	}, map[string]golden{
+		"mysql":     {sql: "WITH RECURSIVE `tree` AS (SELECT `Category`.`ID`,`Category`.`PARENT_ID` FROM `Category` WHERE `ID`=? UNION ALL SELECT `Category`.`ID`,`Category`.`PARENT_ID` FROM `Category` JOIN `tree` ON `Category`.`PARENT_ID`=`tree`.`ID`) SELECT `tree`.`ID` FROM `tree`", args: []interface{}{1}},
+		"mssql":     {sql: `WITH [tree] AS (SELECT [Category].[ID],[Category].[PARENT_ID] FROM [Category] WHERE [ID]=? UNION ALL SELECT [Category].[ID],[Category].[PARENT_ID] FROM [Category] JOIN [tree] ON [Category].[PARENT_ID]=[tree].[ID]) SELECT [tree].[ID] FROM [tree]`, args: []interface{}{1}},
+		"mssql2005": {sql: `WITH [tree] AS (SELECT [Category].[ID],[Category].[PARENT_ID] FROM [Category] WHERE [ID]=? UNION ALL SELECT [Category].[ID],[Category].[PARENT_ID] FROM [Category] JOIN [tree] ON [Category].[PARENT_ID]=[tree].[ID]) SELECT [tree].[ID] FROM [tree]`, args: []interface{}{1}},
+		"sqlite":    {sql: `WITH RECURSIVE "tree" AS (SELECT "Category"."ID","Category"."PARENT_ID" FROM "Category" WHERE "ID"=? UNION ALL SELECT "Category"."ID","Category"."PARENT_ID" FROM "Category" JOIN "tree" ON "Category"."PARENT_ID"="tree"."ID") SELECT "tree"."ID" FROM "tree"`, args: []interface{}{1}},
+		"postgres":  {sql: `WITH RECURSIVE "tree" AS (SELECT "Category"."ID","Category"."PARENT_ID" FROM "Category" WHERE "ID"=$1 UNION ALL SELECT "Category"."ID","Category"."PARENT_ID" FROM "Category" JOIN "tree" ON "Category"."PARENT_ID"="tree"."ID") SELECT "tree"."ID" FROM "tree"`, args: []interface{}{1}},
+	})
+}
+
+func TestWithNested(t *testing.T) {
+	// WITH must be at the beginning of a statement, so a select with ctes can't be nested
+	fail := map[string]golden{"mysql": {err: true}, "mssql": {err: true}, "mssql2005": {err: true}, "sqlite": {err: true}, "postgres": {err: true}}
+	u := T("User")
+
+	// subquery of filter
+	testBuild(t, func(d Dialect) interface{} {
+		sc := newSelectContext(nil, d, &SelectInfo{columns: u.C("NAME").columns})
+		sc.From(u).Where(F().AddT("ID", FILTER_IN, withTop(d)))
+		return sc
+	}, fail)
+
+	// derived table
+	testBuild(t, func(d Dialect) interface{} {
+		x := SubT(withTop(d), "x")
+		sc := newSelectContext(nil, d, &SelectInfo{columns: x.C("USER_ID").columns})
+		sc.From(x)
+		return sc
+	}, fail)
+
+	// source of insert
+	testBuild(t, func(d Dialect) interface{} {
+		return newInsertContext(nil, d, &InsertInfo{table: "B"}).Columns("ID").From(withTop(d))
+	}, fail)
+
+	// member of compound
+	testBuild(t, func(d Dialect) interface{} {
+		sc := newSelectContext(nil, d, &SelectInfo{columns: u.C("ID").columns})
+		sc.From(u)
+		return newCompoundContext(nil, d, &CompoundInfo{queries: []interface{}{withTop(d)}}).Union(sc)
+	}, fail)
+}